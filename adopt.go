@@ -0,0 +1,103 @@
+package ctxexec
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ErrProcessGone is returned by Adopt when the recorded process is no
+// longer running, or its pid was reused by another program
+var ErrProcessGone = errors.New("ctxexec: process is no longer running")
+
+// ProcessRecord is what a supervisor persists about a running command to
+// adopt it, rather than orphan it or start it twice, after a restart
+type ProcessRecord struct {
+	Pid      int               // Pid is the process id
+	Args     []string          // Args are the command line arguments
+	Dir      string            // Dir is the working directory
+	Labels   map[string]string // Labels are the command's Labels
+	Restarts int               // Restarts counts the restarts of the command by the supervisor
+	Started  time.Time         // Started is when the process started
+}
+
+// Record returns the ProcessRecord of the started command
+func (c *CtxCmd) Record() ProcessRecord {
+	r := ProcessRecord{Pid: pidOf(c.Cmd), Args: c.Args, Dir: c.Dir, Labels: c.Labels}
+	for _, e := range c.Timeline() {
+		if e.Kind == EventStarted {
+			r.Started = e.Time
+		}
+	}
+	return r
+}
+
+// SaveRecords atomically writes the records to the file at path, as JSON
+func SaveRecords(path string, records []ProcessRecord) error {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadRecords reads the records saved with SaveRecords, none if the file
+// doesn't exist
+func LoadRecords(path string) ([]ProcessRecord, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []ProcessRecord
+	return records, json.Unmarshal(data, &records)
+}
+
+// Adopt returns a started command for a process recorded by a previous
+// run of the program, so it can be stopped and waited for again. It
+// returns ErrProcessGone if the process exited or its command line doesn't
+// match the record's.
+//
+// An adopted process isn't a child of the program: its exit is detected by
+// checking it still runs every PollInterval, and its exit status is unknown,
+// reaping it returns no error and no process state.
+func Adopt(r ProcessRecord) (*CtxCmd, error) {
+	if r.Pid <= 0 || len(r.Args) == 0 {
+		return nil, errors.New("ctxexec: invalid process record")
+	}
+	p, err := os.FindProcess(r.Pid)
+	if err != nil || !alive(p) {
+		return nil, ErrProcessGone
+	}
+	args, err := processArgs(r.Pid)
+	if err != nil || strings.Join(args, " ") != strings.Join(r.Args, " ") {
+		return nil, ErrProcessGone
+	}
+	cmd := exec.Command(r.Args[0], r.Args[1:]...)
+	cmd.Args, cmd.Dir, cmd.Process = r.Args, r.Dir, p
+	c := New(cmd)
+	c.Labels = r.Labels
+	c.adopted = true
+	c.status = StatusRunning
+	c.timeline.recordStarted()
+	close(c.startedChan())
+	return c, nil
+}
+
+// waitAdopted waits for an adopted process to exit
+func waitAdopted(p *os.Process) error {
+	for alive(p) {
+		time.Sleep(PollInterval)
+	}
+	return nil
+}
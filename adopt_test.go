@@ -0,0 +1,55 @@
+package ctxexec
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestAdopt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ctxexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c := New(exec.Command("sleep", "5"))
+	c.Labels = map[string]string{"job": "etl"}
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	go c.Cmd.Wait() // the previous supervisor reaps its child
+	r := c.Record()
+	r.Restarts = 2
+	path := filepath.Join(dir, "records.json")
+	if err := SaveRecords(path, []ProcessRecord{r}); err != nil {
+		t.Fatal(err)
+	}
+	records, err := LoadRecords(path)
+	if err != nil || len(records) != 1 || !reflect.DeepEqual(records[0].Args, r.Args) || records[0].Restarts != 2 {
+		t.Fatalf("expected the record back, got %+v, %v", records, err)
+	}
+
+	adopted, err := Adopt(records[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adopted.Labels["job"] != "etl" {
+		t.Fatalf("expected the labels back, got %v", adopted.Labels)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := adopted.Stop(ctx); err != nil {
+		t.Fatalf("expected the adopted process to stop, got %v", err)
+	}
+
+	records[0].Pid = adopted.Process.Pid
+	if _, err := Adopt(records[0]); err != ErrProcessGone {
+		t.Fatalf("expected %v, got %v", ErrProcessGone, err)
+	}
+}
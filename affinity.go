@@ -0,0 +1,21 @@
+package ctxexec
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// checkAffinity rejects the CPUAffinity on platforms without CPU affinity
+// and negative CPUs, before the command is started
+func checkAffinity(cpus []int) error {
+	if !affinitySupported {
+		return errors.New("ctxexec: cpu affinity isn't supported on " + runtime.GOOS)
+	}
+	for _, cpu := range cpus {
+		if cpu < 0 {
+			return fmt.Errorf("ctxexec: invalid cpu %d", cpu)
+		}
+	}
+	return nil
+}
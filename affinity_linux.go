@@ -0,0 +1,34 @@
+package ctxexec
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// affinitySupported is true where setAffinity is implemented
+const affinitySupported = true
+
+// setAffinity sets the CPU affinity of the calling thread with
+// sched_setaffinity, the program it executes and the processes it forks
+// inherit it
+func setAffinity(cpus []int) error {
+	var mask []uint64
+	for _, cpu := range cpus {
+		if cpu < 0 {
+			return fmt.Errorf("invalid cpu %d", cpu)
+		}
+		for len(mask) <= cpu/64 {
+			mask = append(mask, 0)
+		}
+		mask[cpu/64] |= 1 << uint(cpu%64)
+	}
+	if len(mask) == 0 {
+		return nil
+	}
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, 0, uintptr(len(mask)*8), uintptr(unsafe.Pointer(&mask[0])))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package ctxexec
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func cpusAllowed(t *testing.T, pid int) string {
+	status, err := ioutil.ReadFile("/proc/" + strconv.Itoa(pid) + "/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(status), "\n") {
+		if strings.HasPrefix(line, "Cpus_allowed_list:") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "Cpus_allowed_list:"))
+		}
+	}
+	return ""
+}
+
+func TestCPUAffinity(t *testing.T) {
+	c := New(exec.Command("sleep", "5"), WithCPUAffinity(0))
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if cpus := cpusAllowed(t, c.Process.Pid); cpus != "0" {
+		t.Fatalf("expected the process pinned to cpu 0, got %q", cpus)
+	}
+}
+
+func TestCPUAffinity_Invalid(t *testing.T) {
+	if err := New(exec.Command("true"), WithCPUAffinity(-1)).Start(); err == nil {
+		t.Fatal("expected a negative cpu to be rejected")
+	}
+	// no online cpu in the mask
+	if err := New(exec.Command("true"), WithCPUAffinity(4095)).Run(context.Background()); err == nil {
+		t.Fatal("expected an offline cpu to fail the command")
+	}
+}
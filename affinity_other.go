@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package ctxexec
+
+import "errors"
+
+// affinitySupported is true where setAffinity is implemented
+const affinitySupported = false
+
+// setAffinity returns an error, CPU affinity is only supported on linux
+func setAffinity(cpus []int) error {
+	return errors.New("cpu affinity isn't supported on this platform")
+}
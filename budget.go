@@ -0,0 +1,75 @@
+package ctxexec
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// ErrBudgetExhausted is returned when starting a command on a Budget with
+// no time left
+var ErrBudgetExhausted = errors.New("ctxexec: runtime budget exhausted")
+
+// Budget is a runtime budget shared by a set of commands, such as the
+// stages of a job that must finish within 10 minutes overall. Each command
+// started on the budget gets a package-managed deadline of the remaining
+// budget, and the runtime it consumed is deducted once it exits, so the
+// last commands get correspondingly tighter deadlines.
+//
+// Commands running concurrently don't reserve budget, each may use all of
+// what remains when it starts.
+type Budget struct {
+	mu        sync.Mutex
+	remaining time.Duration
+	cpu       bool
+}
+
+// NewBudget returns a Budget of total wall-clock time
+func NewBudget(total time.Duration) *Budget {
+	return &Budget{remaining: total}
+}
+
+// NewCPUBudget returns a Budget of total CPU time, user and system, as
+// reported once the processes exited. The deadlines of the commands are
+// still wall-clock ones, of the remaining CPU time.
+func NewCPUBudget(total time.Duration) *Budget {
+	return &Budget{remaining: total, cpu: true}
+}
+
+// Remaining returns the budget left
+func (b *Budget) Remaining() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.remaining
+}
+
+// Start starts the command with StartContext, with a package-managed
+// deadline of the remaining budget unless it already has an earlier one.
+// It returns ErrBudgetExhausted when there is no budget left.
+func (b *Budget) Start(ctx context.Context, c *CtxCmd) error {
+	remaining := b.Remaining()
+	if remaining <= 0 {
+		return ErrBudgetExhausted
+	}
+	var started time.Time
+	c.onExit = append(c.onExit, func() {
+		used := time.Since(started)
+		if b.cpu {
+			used = c.ProcessState.UserTime() + c.ProcessState.SystemTime()
+		}
+		b.mu.Lock()
+		b.remaining -= used
+		b.mu.Unlock()
+	})
+	started = time.Now()
+	if err := c.StartContext(ctx); err != nil {
+		return err
+	}
+	deadline := started.Add(remaining)
+	if d, ok := c.Deadline(); !ok || deadline.Before(d) {
+		c.SetDeadline(deadline)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestBudget(t *testing.T) {
+	b := NewBudget(time.Millisecond * 300)
+	ctx := context.Background()
+	first := New(exec.Command("sleep", "0.1"))
+	if err := b.Start(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	WaitAll(ctx, first)
+	if r := b.Remaining(); r > time.Millisecond*200 {
+		t.Fatalf("expected the first command's runtime deducted, %v remaining", r)
+	}
+	second := New(exec.Command("sleep", "5"))
+	if err := b.Start(ctx, second); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := second.Deadline(); time.Until(d) > time.Millisecond*200 {
+		t.Fatalf("expected a deadline of the remaining budget, got %v", time.Until(d))
+	}
+	if err := second.Wait(ctx); err == nil {
+		t.Fatal("expected the second command to be stopped at its deadline")
+	}
+	if err := b.Start(ctx, New(exec.Command("true"))); err != ErrBudgetExhausted {
+		t.Fatalf("expected %v, got %v", ErrBudgetExhausted, err)
+	}
+}
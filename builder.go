@@ -0,0 +1,146 @@
+package ctxexec
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Builder builds a CtxCmd fluently, as in
+//
+//	c := ctxexec.Build("pg_dump").Arg("-Fc").Env("PGPASSWORD", pw).
+//		Dir(tmp).Timeout(5 * time.Minute).Grace(30 * time.Second).
+//		StdoutFile(path).Cmd()
+type Builder struct {
+	name       string
+	args       []string
+	env        []string
+	dir        string
+	labels     map[string]string
+	timeout    time.Duration
+	grace      time.Duration
+	stdin      io.Reader
+	stdout     io.Writer
+	stderr     io.Writer
+	stdoutFile string
+	stderrFile string
+}
+
+// Build returns a Builder for the named program, resolved as by exec.Command
+func Build(name string) *Builder {
+	return &Builder{name: name}
+}
+
+// Arg appends arguments
+func (b *Builder) Arg(args ...string) *Builder {
+	b.args = append(b.args, args...)
+	return b
+}
+
+// Env sets an environment variable on top of the current environment
+func (b *Builder) Env(key, value string) *Builder {
+	b.env = append(b.env, key+"="+value)
+	return b
+}
+
+// Dir sets the working directory
+func (b *Builder) Dir(dir string) *Builder {
+	b.dir = dir
+	return b
+}
+
+// Label sets a label, see CtxCmd.Labels
+func (b *Builder) Label(key, value string) *Builder {
+	if b.labels == nil {
+		b.labels = map[string]string{}
+	}
+	b.labels[key] = value
+	return b
+}
+
+// Timeout sets the MaxRuntime
+func (b *Builder) Timeout(d time.Duration) *Builder {
+	b.timeout = d
+	return b
+}
+
+// Grace sets the Grace
+func (b *Builder) Grace(d time.Duration) *Builder {
+	b.grace = d
+	return b
+}
+
+// Stdin sets the standard input
+func (b *Builder) Stdin(r io.Reader) *Builder {
+	b.stdin = r
+	return b
+}
+
+// Stdout sets the standard output
+func (b *Builder) Stdout(w io.Writer) *Builder {
+	b.stdout, b.stdoutFile = w, ""
+	return b
+}
+
+// Stderr sets the standard error
+func (b *Builder) Stderr(w io.Writer) *Builder {
+	b.stderr, b.stderrFile = w, ""
+	return b
+}
+
+// StdoutFile sends the standard output to the file at path, see Cmd
+func (b *Builder) StdoutFile(path string) *Builder {
+	b.stdout, b.stdoutFile = nil, path
+	return b
+}
+
+// StderrFile sends the standard error to the file at path, see Cmd
+func (b *Builder) StderrFile(path string) *Builder {
+	b.stderr, b.stderrFile = nil, path
+	return b
+}
+
+// Cmd returns the CtxCmd built, created with New. The files of StdoutFile
+// and StderrFile are created, or truncated, when the command starts, after
+// its Before commands, and closed in the parent once it started. Writers
+// set on the command in the meantime, such as by CaptureTail, also receive
+// the output.
+func (b *Builder) Cmd() *CtxCmd {
+	cmd := exec.Command(b.name, b.args...)
+	if b.env != nil {
+		cmd.Env = append(os.Environ(), b.env...)
+	}
+	cmd.Dir = b.dir
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = b.stdin, b.stdout, b.stderr
+	c := New(cmd)
+	c.outputFiles = [2]string{b.stdoutFile, b.stderrFile}
+	if b.labels != nil {
+		c.Labels = b.labels
+	}
+	if b.timeout > 0 {
+		c.MaxRuntime = b.timeout
+	}
+	if b.grace > 0 {
+		c.Grace = b.grace
+	}
+	return c
+}
+
+// openOutputFiles creates the files of the Builder's StdoutFile and
+// StderrFile, closed in the parent once the command started or failed to
+// start
+func (c *CtxCmd) openOutputFiles() error {
+	for i, w := range []*io.Writer{&c.Cmd.Stdout, &c.Cmd.Stderr} {
+		if c.outputFiles[i] == "" {
+			continue
+		}
+		file, err := os.Create(c.outputFiles[i])
+		if err != nil {
+			return err
+		}
+		c.closeAfterStart = append(c.closeAfterStart, file)
+		*w = teeTo(*w, file)
+	}
+	return nil
+}
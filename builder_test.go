@@ -0,0 +1,37 @@
+package ctxexec
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestBuild(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ctxexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out")
+	c := Build("bash").Arg("-c", `echo "$GREETING from $(pwd)"`).
+		Env("GREETING", "hello").Dir(dir).
+		Timeout(time.Minute).Grace(time.Second).
+		StdoutFile(path).Cmd()
+	if c.MaxRuntime != time.Minute || c.Grace != time.Second {
+		t.Fatalf("expected the timeout and grace set, got %v and %v", c.MaxRuntime, c.Grace)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected the file to be created on start, got %v", err)
+	}
+	c.Start()
+	WaitAll(context.Background(), c)
+	out, _ := ioutil.ReadFile(path)
+	realDir, _ := filepath.EvalSymlinks(dir)
+	if want := "hello from " + realDir + "\n"; string(out) != want {
+		t.Fatalf("expected %q, got %q", want, out)
+	}
+}
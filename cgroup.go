@@ -0,0 +1,17 @@
+package ctxexec
+
+// CgroupParent is the cgroup v2 directory the transient cgroups of the
+// commands with a Cgroup are created in. It must be writable by the process
+// and, per the cgroup v2 "no internal processes" rule, hold no processes
+// itself, such as a cgroup delegated by systemd with Delegate=yes. It may
+// only be empty when the program runs in the root cgroup, which the rule
+// exempts, the commands with a Cgroup fail to start otherwise.
+var CgroupParent = ""
+
+// CgroupLimits are the hard resource limits of the transient cgroup v2 a
+// command is placed in, zero fields mean no limit
+type CgroupLimits struct {
+	Memory   int64   // Memory is memory.max, in bytes
+	CPUQuota float64 // CPUQuota is the number of CPUs the process may use, 0.5 for half of one
+	PIDs     int     // PIDs is pids.max, the maximum number of processes
+}
@@ -0,0 +1,162 @@
+package ctxexec
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// cgroupPeriod is the period of cpu.max in microseconds
+const cgroupPeriod = 100000
+
+// cgroups numbers the transient cgroups of the process
+var cgroups uint64
+
+// joinCgroup creates a transient cgroup with the Cgroup limits and has the
+// process started in it. The returned function kills what is left in the
+// cgroup and removes it.
+func (c *CtxCmd) joinCgroup() (func(), error) {
+	parent := CgroupParent
+	if parent == "" {
+		var err error
+		if parent, err = selfCgroup(); err != nil {
+			return nil, err
+		}
+		if root, _ := cgroupRoot(); parent != root {
+			return nil, fmt.Errorf("ctxexec: cgroup: CgroupParent is required, the cgroup of the program %s holds processes", parent)
+		}
+	}
+	l := c.Cgroup
+	files := map[string]string{}
+	var controllers []string
+	if l.Memory > 0 {
+		files["memory.max"] = strconv.FormatInt(l.Memory, 10)
+		controllers = append(controllers, "+memory")
+	}
+	if l.CPUQuota > 0 {
+		files["cpu.max"] = fmt.Sprintf("%d %d", int64(l.CPUQuota*cgroupPeriod), cgroupPeriod)
+		controllers = append(controllers, "+cpu")
+	}
+	if l.PIDs > 0 {
+		files["pids.max"] = strconv.Itoa(l.PIDs)
+		controllers = append(controllers, "+pids")
+	}
+	if err := enableControllers(parent, controllers); err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(parent, fmt.Sprintf("ctxexec-%d-%d", os.Getpid(), atomic.AddUint64(&cgroups, 1)))
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return nil, fmt.Errorf("ctxexec: cgroup: %v", err)
+	}
+	for name, value := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(value), 0); err != nil {
+			os.Remove(dir)
+			return nil, fmt.Errorf("ctxexec: cgroup: %v", err)
+		}
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		os.Remove(dir)
+		return nil, fmt.Errorf("ctxexec: cgroup: %v", err)
+	}
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	c.SysProcAttr.UseCgroupFD = true
+	c.SysProcAttr.CgroupFD = int(f.Fd())
+	c.closeAfterStart = append(c.closeAfterStart, f)
+	return func() { removeCgroup(dir) }, nil
+}
+
+// enableControllers enables the controllers for the children of the
+// cgroup, unless they already are
+func enableControllers(dir string, controllers []string) error {
+	if len(controllers) == 0 {
+		return nil
+	}
+	path := filepath.Join(dir, "cgroup.subtree_control")
+	err := ioutil.WriteFile(path, []byte(strings.Join(controllers, " ")), 0)
+	if err == nil {
+		return nil
+	}
+	// writing fails without delegation even when they are enabled
+	data, _ := ioutil.ReadFile(path)
+	enabled := strings.Fields(string(data))
+	for _, c := range controllers {
+		found := false
+		for _, e := range enabled {
+			found = found || "+"+e == c
+		}
+		if !found {
+			return fmt.Errorf("ctxexec: cgroup: enable the %s controller in %s: %v", c[1:], dir, err)
+		}
+	}
+	return nil
+}
+
+// removeCgroup kills the processes left in the cgroup, such as orphaned
+// descendants, and removes it once they exited
+func removeCgroup(dir string) {
+	ioutil.WriteFile(filepath.Join(dir, "cgroup.kill"), []byte("1"), 0)
+	for i := 0; i < 100; i++ {
+		if err := os.Remove(dir); err == nil || os.IsNotExist(err) {
+			return
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+// selfCgroup returns the directory of the cgroup v2 of the current process
+func selfCgroup() (string, error) {
+	root, err := cgroupRoot()
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if path := strings.TrimPrefix(s.Text(), "0::"); path != s.Text() {
+			return filepath.Join(root, path), nil
+		}
+	}
+	return "", errNoCgroup2
+}
+
+var errNoCgroup2 = errors.New("ctxexec: cgroup: no cgroup v2 hierarchy")
+
+// cgroupRoot returns the mount point of the cgroup v2 hierarchy, which is
+// /sys/fs/cgroup/unified rather than /sys/fs/cgroup on hybrid systems
+func cgroupRoot() (string, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// the mount point is the 5th field, the file system type follows
+		// the "-" separator after the optional fields
+		fields := strings.Fields(s.Text())
+		for i := 6; i < len(fields)-1; i++ {
+			if fields[i] == "-" {
+				if fields[i+1] == "cgroup2" {
+					return fields[4], nil
+				}
+				break
+			}
+		}
+	}
+	return "", errNoCgroup2
+}
@@ -0,0 +1,68 @@
+package ctxexec
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestCgroup(t *testing.T) {
+	c := New(exec.Command("sleep", "5"), WithCgroup(CgroupLimits{}))
+	if err := c.StartContext(context.Background()); err != nil {
+		t.Skipf("cgroup v2 unavailable: %v", err)
+	}
+	cgroup, err := ioutil.ReadFile("/proc/" + strconv.Itoa(c.Process.Pid) + "/cgroup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var path string
+	for _, line := range strings.Split(string(cgroup), "\n") {
+		if strings.HasPrefix(line, "0::") {
+			path = strings.TrimPrefix(line, "0::")
+		}
+	}
+	if !strings.HasPrefix(filepath.Base(path), "ctxexec-") {
+		c.Close()
+		t.Fatalf("expected the process in a transient cgroup, got %q", path)
+	}
+	root, _ := cgroupRoot()
+	c.Close()
+	if _, err := os.Stat(filepath.Join(root, path)); !os.IsNotExist(err) {
+		t.Fatalf("expected the cgroup to be removed, got %v", err)
+	}
+}
+
+func TestCgroupRoot(t *testing.T) {
+	root, err := cgroupRoot()
+	if err != nil {
+		t.Skip(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "cgroup.procs")); err != nil {
+		t.Fatalf("expected %s to be a cgroup v2 hierarchy: %v", root, err)
+	}
+}
+
+func TestEnableControllers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ctxexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// a directory can't be written, like the subtree_control of a cgroup
+	// holding processes
+	if err := os.Mkdir(filepath.Join(dir, "cgroup.subtree_control"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := enableControllers(dir, []string{"+memory"}); err == nil || !strings.Contains(err.Error(), "memory") {
+		t.Fatalf("expected the missing controller reported, got %v", err)
+	}
+	if err := enableControllers(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+}
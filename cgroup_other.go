@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package ctxexec
+
+import "errors"
+
+// joinCgroup returns an error, cgroups are only supported on linux
+func (c *CtxCmd) joinCgroup() (func(), error) {
+	return nil, errors.New("ctxexec: cgroups aren't supported on this platform")
+}
@@ -0,0 +1,66 @@
+package ctxexec
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// CleanupTimeout bounds the context passed to cleanup functions
+var CleanupTimeout = time.Minute
+
+// Cleanup registers a function to be called once the process is reaped,
+// even when it was stopped because the run context was done. Cleanup
+// functions are called in last added, first called order with a context
+// bounded by CleanupTimeout that isn't derived from the run context.
+//
+// A function registered once the cleanup functions were called, after the
+// process was reaped, is called right away, before Cleanup returns, with its
+// own context bounded by CleanupTimeout.
+//
+// Commands with cleanup functions are reaped in the background, use Wait
+// rather than Cmd.Wait on them.
+func (c *CtxCmd) Cleanup(f func(ctx context.Context)) {
+	c.mu.Lock()
+	if c.cleanedUp {
+		c.mu.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), CleanupTimeout)
+		defer cancel()
+		f(ctx)
+		return
+	}
+	c.cleanups = append(c.cleanups, f)
+	c.mu.Unlock()
+	if c.Cmd.Process != nil {
+		c.reap()
+	}
+}
+
+// CleanupCmd registers a command to be run as a cleanup function, bounded
+// by its MaxRuntime or by DefaultHookTimeout
+func (c *CtxCmd) CleanupCmd(cmd *CtxCmd) {
+	c.Cleanup(func(ctx context.Context) { runHook(ctx, cmd) })
+}
+
+// runCleanups calls the cleanup functions in reverse order, then the ones
+// registered meanwhile, until none is left
+func (c *CtxCmd) runCleanups() {
+	var ctx context.Context
+	for {
+		c.mu.Lock()
+		cleanups := c.cleanups
+		c.cleanups, c.cleanedUp = nil, len(cleanups) == 0
+		c.mu.Unlock()
+		if len(cleanups) == 0 {
+			return
+		}
+		if ctx == nil {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(context.Background(), CleanupTimeout)
+			defer cancel()
+		}
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i](ctx)
+		}
+	}
+}
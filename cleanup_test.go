@@ -0,0 +1,46 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestCleanup(t *testing.T) {
+	var calls []int
+	c := New(exec.Command("sleep", "5"))
+	c.Cleanup(func(ctx context.Context) { calls = append(calls, 1) })
+	c.Start()
+	c.Cleanup(func(ctx context.Context) {
+		if ctx.Err() != nil {
+			t.Errorf("expected a live cleanup context, got %v", ctx.Err())
+		}
+		calls = append(calls, 2)
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	c.Wait(ctx)
+	if !reflect.DeepEqual(calls, []int{2, 1}) {
+		t.Fatalf("expected cleanups in reverse order, got %v", calls)
+	}
+}
+
+func TestCleanup_AfterReap(t *testing.T) {
+	c := New(exec.Command("true"))
+	c.Cleanup(func(ctx context.Context) {})
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	called := false
+	c.Cleanup(func(ctx context.Context) {
+		if _, ok := ctx.Deadline(); ok && ctx.Err() == nil {
+			called = true
+		}
+	})
+	if !called {
+		t.Fatal("expected the late cleanup to be called right away with a cleanup context")
+	}
+}
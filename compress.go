@@ -0,0 +1,70 @@
+package ctxexec
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+)
+
+// CompressedOutput describes the output of a command compressed with
+// CompressOutput
+type CompressedOutput struct {
+	Format         string // Format is the compression format, "gzip"
+	Size           int64  // Size is the size of the output before compression
+	CompressedSize int64  // CompressedSize is the size of the stream written
+	Err            error  // Err is the error writing or completing the stream, if any
+}
+
+// CompressOutput sends the command's standard output and error, combined,
+// as a gzip stream to w, compressed with level as in compress/gzip. The
+// stream is completed once the process is reaped, w isn't closed. It must
+// be called before the command is started.
+//
+// An error writing or completing the stream is returned by Wait, unless it
+// returns another error, and the Result of RunResult describes the stream.
+//
+// The command is reaped in the background, use Wait rather than Cmd.Wait on it.
+func (c *CtxCmd) CompressOutput(w io.Writer, level int) error {
+	if c.Cmd.Process != nil {
+		return errors.New("ctxexec: compression requested after the process started")
+	}
+	if c.Cmd.Stdout != nil || c.Cmd.Stderr != nil {
+		return errors.New("ctxexec: Stdout or Stderr already set")
+	}
+	out := &countWriter{w: w}
+	gz, err := gzip.NewWriterLevel(out, level)
+	if err != nil {
+		return err
+	}
+	in := &countWriter{w: gz}
+	c.Cmd.Stdout, c.Cmd.Stderr = in, in
+	c.onExit = append(c.onExit, func() {
+		err := gz.Close()
+		if in.err != nil {
+			err = in.err
+		}
+		if err != nil && c.waitErr == nil {
+			c.waitErr = err
+		}
+		c.mu.Lock()
+		c.compressed = &CompressedOutput{Format: "gzip", Size: in.n, CompressedSize: out.n, Err: err}
+		c.mu.Unlock()
+	})
+	return nil
+}
+
+// countWriter counts the bytes written to w and keeps the first error
+type countWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,60 @@
+package ctxexec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"os/exec"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestCompressOutput(t *testing.T) {
+	var buf bytes.Buffer
+	c := New(exec.Command("bash", "-c", `echo out; echo err >&2`))
+	if err := c.CompressOutput(&buf, gzip.BestSpeed); err != nil {
+		t.Fatal(err)
+	}
+	c.Start()
+	WaitAll(context.Background(), c)
+	r, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil || string(out) != "out\nerr\n" {
+		t.Fatalf("unexpected output %q, %v", out, err)
+	}
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestCompressOutput_Result(t *testing.T) {
+	var buf bytes.Buffer
+	c := New(exec.Command("bash", "-c", `printf '%01000d' 0`))
+	if err := c.CompressOutput(&buf, gzip.BestCompression); err != nil {
+		t.Fatal(err)
+	}
+	r, err := c.RunResult(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Compressed == nil || r.Compressed.Size != 1000 || r.Compressed.CompressedSize != int64(buf.Len()) || r.Compressed.Err != nil {
+		t.Fatalf("unexpected compressed output %+v, %d bytes written", r.Compressed, buf.Len())
+	}
+
+	c = New(exec.Command("true"))
+	if err := c.CompressOutput(failingWriter{}, gzip.BestSpeed); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Run(context.Background()); err == nil || err.Error() != "disk full" {
+		t.Fatalf("expected the error completing the stream, got %v", err)
+	}
+}
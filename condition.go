@@ -0,0 +1,68 @@
+package ctxexec
+
+import (
+	"net"
+	"os"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Condition blocks until a prerequisite for starting a command is satisfied.
+//
+// It returns the context's error when the context is done first.
+type Condition func(ctx context.Context) error
+
+// PollInterval is how often the conditions provided by the package are checked
+var PollInterval = 100 * time.Millisecond
+
+// FileExists returns a Condition satisfied once path exists
+func FileExists(path string) Condition {
+	return func(ctx context.Context) error {
+		return poll(ctx, func() bool {
+			_, err := os.Stat(path)
+			return err == nil
+		})
+	}
+}
+
+// TCPReachable returns a Condition satisfied once a TCP connection to addr succeeds
+func TCPReachable(addr string) Condition {
+	return func(ctx context.Context) error {
+		return poll(ctx, func() bool {
+			conn, err := net.DialTimeout("tcp", addr, PollInterval)
+			if err != nil {
+				return false
+			}
+			conn.Close()
+			return true
+		})
+	}
+}
+
+// Ready returns a Condition satisfied once the other command has started
+// and its ReadyWhen condition, if any, is satisfied
+func Ready(other *CtxCmd) Condition {
+	return func(ctx context.Context) error {
+		select {
+		case <-other.startedChan():
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// poll calls check every PollInterval until it returns true or the context is done
+func poll(ctx context.Context, check func() bool) error {
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+	for !check() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
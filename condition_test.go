@@ -0,0 +1,71 @@
+package ctxexec
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestStartWhen_FileExists(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ctxexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ready")
+	time.AfterFunc(time.Millisecond*200, func() { ioutil.WriteFile(path, nil, 0644) })
+
+	c := New(exec.Command("true"))
+	c.StartWhen = []Condition{FileExists(path)}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+	if err := c.StartContext(ctx); err != nil {
+		t.Fatalf("expected start, got %v", err)
+	}
+	c.Cmd.Wait()
+}
+
+func TestStartWhen_Canceled(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	c := New(exec.Command("true"))
+	c.StartWhen = []Condition{TCPReachable(addr)}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*300)
+	defer cancel()
+	if err := c.StartContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if c.Cmd.Process != nil {
+		t.Fatal("expected the command not to start")
+	}
+}
+
+func TestStartWhen_Ready(t *testing.T) {
+	dep := New(exec.Command("sleep", "1"))
+	c := New(exec.Command("true"))
+	c.StartWhen = []Condition{Ready(dep)}
+	errc := make(chan error)
+	go func() { errc <- c.StartContext(context.Background()) }()
+	select {
+	case err := <-errc:
+		t.Fatalf("expected to wait for dependency, got %v", err)
+	case <-time.After(time.Millisecond * 100):
+	}
+	dep.Start()
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	c.Cmd.Wait()
+	dep.Cmd.Wait()
+}
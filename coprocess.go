@@ -0,0 +1,197 @@
+package ctxexec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Codec encodes the messages exchanged with a co-process
+type Codec int
+
+const (
+	// JSON encodes messages with encoding/json
+	JSON Codec = iota
+	// Gob encodes messages with encoding/gob
+	Gob
+)
+
+// MaxMessageSize is the size above which a co-process message is
+// considered a protocol desync
+var MaxMessageSize = 64 << 20
+
+// ErrDesync is returned when a co-process response can't be decoded, the
+// process is restarted on the next call
+var ErrDesync = errors.New("ctxexec: co-process protocol desync")
+
+// CoProcess exchanges request and response messages with a long-running
+// child over its standard input and output. Each message is a 4-byte big
+// endian length followed by the encoded message.
+//
+// The child is started on the first call. When a call fails, because the
+// context is done or the response can't be decoded, the child is stopped
+// and restarted on the next call since the stream may be out of sync.
+type CoProcess struct {
+	factory func() *exec.Cmd
+	codec   Codec
+
+	mu     sync.Mutex
+	cmd    *CtxCmd
+	stdin  *os.File
+	stdout *os.File
+}
+
+// NewCoProcess returns a CoProcess starting its child with the factory
+func NewCoProcess(factory func() *exec.Cmd, codec Codec) *CoProcess {
+	return &CoProcess{factory: factory, codec: codec}
+}
+
+// Call sends the request to the child and decodes its response into resp.
+// The call is bounded by the context.
+func (p *CoProcess) Call(ctx context.Context, req, resp interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if p.cmd == nil {
+		if err := p.start(); err != nil {
+			return err
+		}
+	}
+	err := p.call(ctx, req, resp)
+	if err != nil {
+		logf(ctx, Errors, "pid %d: co-process call: %v", pidOf(p.cmd.Cmd), err)
+		p.reset()
+		if cerr := ctx.Err(); cerr != nil {
+			return cerr
+		}
+		if os.IsTimeout(err) { // the deadline set from the context passed
+			return context.DeadlineExceeded
+		}
+	}
+	return err
+}
+
+func (p *CoProcess) call(ctx context.Context, req, resp interface{}) error {
+	stdin, stdout := p.stdin, p.stdout
+	deadline, _ := ctx.Deadline()
+	stdin.SetWriteDeadline(deadline)
+	stdout.SetReadDeadline(deadline)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			stdin.SetWriteDeadline(time.Now())
+			stdout.SetReadDeadline(time.Now())
+		case <-done:
+		}
+	}()
+	if err := p.write(req); err != nil {
+		return err
+	}
+	return p.read(resp)
+}
+
+func (p *CoProcess) write(msg interface{}) error {
+	var buf bytes.Buffer
+	buf.Write(make([]byte, 4))
+	var err error
+	if p.codec == Gob {
+		err = gob.NewEncoder(&buf).Encode(msg)
+	} else {
+		err = json.NewEncoder(&buf).Encode(msg)
+	}
+	if err != nil {
+		return err
+	}
+	b := buf.Bytes()
+	binary.BigEndian.PutUint32(b, uint32(len(b)-4))
+	_, err = p.stdin.Write(b)
+	return err
+}
+
+func (p *CoProcess) read(msg interface{}) error {
+	var size [4]byte
+	if _, err := io.ReadFull(p.stdout, size[:]); err != nil {
+		return err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if int64(n) > int64(MaxMessageSize) {
+		return ErrDesync
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(p.stdout, b); err != nil {
+		return err
+	}
+	var err error
+	if p.codec == Gob {
+		err = gob.NewDecoder(bytes.NewReader(b)).Decode(msg)
+	} else {
+		err = json.Unmarshal(b, msg)
+	}
+	if err != nil {
+		return ErrDesync
+	}
+	return nil
+}
+
+// start starts the child with its standard input and output connected
+func (p *CoProcess) start() error {
+	inR, inW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		inR.Close()
+		inW.Close()
+		return err
+	}
+	cmd := p.factory()
+	cmd.Stdin, cmd.Stdout = inR, outW
+	c := New(cmd)
+	c.closeAfterStart = append(c.closeAfterStart, inR, outW)
+	if err := c.Start(); err != nil {
+		inW.Close()
+		outR.Close()
+		return err
+	}
+	p.cmd, p.stdin, p.stdout = c, inW, outR
+	return nil
+}
+
+// reset closes the pipes and stops the child in the background
+func (p *CoProcess) reset() {
+	if p.cmd == nil {
+		return
+	}
+	p.stdin.Close()
+	p.stdout.Close()
+	go p.cmd.Close()
+	p.cmd, p.stdin, p.stdout = nil, nil, nil
+}
+
+// Close closes the child's standard input and stops it, see CtxCmd.Close
+func (p *CoProcess) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmd == nil {
+		return nil
+	}
+	c := p.cmd
+	p.stdin.Close()
+	p.stdout.Close()
+	p.cmd, p.stdin, p.stdout = nil, nil, nil
+	return c.Close()
+}
@@ -0,0 +1,78 @@
+package ctxexec
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// TestCoProcessHelper is the co-process used by the tests, it upper-cases
+// the requests it receives
+func TestCoProcessHelper(t *testing.T) {
+	if os.Getenv("CTXEXEC_HELPER") != "coprocess" {
+		return
+	}
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(os.Stdin, size[:]); err != nil {
+			os.Exit(0)
+		}
+		b := make([]byte, binary.BigEndian.Uint32(size[:]))
+		io.ReadFull(os.Stdin, b)
+		var req string
+		json.Unmarshal(b, &req)
+		switch req {
+		case "garbage":
+			os.Stdout.Write([]byte{0, 0, 0, 1, '{'})
+			continue
+		case "hang":
+			time.Sleep(time.Minute)
+		}
+		resp, _ := json.Marshal(strings.ToUpper(req))
+		binary.BigEndian.PutUint32(size[:], uint32(len(resp)))
+		os.Stdout.Write(append(size[:], resp...))
+	}
+}
+
+func helperCommand(name string) func() *exec.Cmd {
+	return func() *exec.Cmd {
+		cmd := exec.Command(os.Args[0], "-test.run=TestCoProcessHelper")
+		cmd.Env = append(os.Environ(), "CTXEXEC_HELPER="+name)
+		return cmd
+	}
+}
+
+func TestCoProcess(t *testing.T) {
+	p := NewCoProcess(helperCommand("coprocess"), JSON)
+	defer p.Close()
+	ctx := context.Background()
+	var resp string
+	if err := p.Call(ctx, "hello", &resp); err != nil || resp != "HELLO" {
+		t.Fatalf("unexpected response %q, %v", resp, err)
+	}
+	pid := p.cmd.Process.Pid
+
+	if err := p.Call(ctx, "garbage", &resp); err != ErrDesync {
+		t.Fatalf("expected ErrDesync, got %v", err)
+	}
+	tctx, cancel := context.WithTimeout(ctx, time.Millisecond*200)
+	defer cancel()
+	p.Call(ctx, "again", &resp)
+	if err := p.Call(tctx, "hang", &resp); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	if err := p.Call(ctx, "restarted", &resp); err != nil || resp != "RESTARTED" {
+		t.Fatalf("unexpected response %q, %v", resp, err)
+	}
+	if p.cmd.Process.Pid == pid {
+		t.Fatal("expected the co-process to be restarted")
+	}
+}
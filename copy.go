@@ -0,0 +1,80 @@
+package ctxexec
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// CopyAbortTimeout bounds how long CopyContext waits for a blocked read
+// to return once the context is done
+var CopyAbortTimeout = 100 * time.Millisecond
+
+// CopyContext copies from src to dst until either EOF is reached on src, an
+// error occurs or the context is done. It returns the number of bytes
+// written and the first error encountered, the context's error when done.
+//
+// When the context is done, src is closed if it is an io.Closer and
+// CopyContext returns within CopyAbortTimeout even if a read is blocked.
+// Data read after the context is done is discarded, dst is never written
+// to once CopyContext returned.
+func CopyContext(ctx context.Context, dst io.Writer, src io.Reader) (int64, error) {
+	var (
+		mu      sync.Mutex
+		written int64
+		aborted bool
+	)
+	done := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			nr, rerr := src.Read(buf)
+			if nr > 0 {
+				mu.Lock()
+				if aborted {
+					mu.Unlock()
+					return
+				}
+				nw, werr := dst.Write(buf[:nr])
+				written += int64(nw)
+				mu.Unlock()
+				if werr == nil && nw != nr {
+					werr = io.ErrShortWrite
+				}
+				if werr != nil {
+					done <- werr
+					return
+				}
+			}
+			if rerr == io.EOF {
+				done <- nil
+				return
+			}
+			if rerr != nil {
+				done <- rerr
+				return
+			}
+		}
+	}()
+	select {
+	case err := <-done:
+		return written, err
+	case <-ctx.Done():
+	}
+	mu.Lock()
+	aborted = true
+	n := written
+	mu.Unlock()
+	if c, ok := src.(io.Closer); ok {
+		c.Close()
+	}
+	timer := time.NewTimer(CopyAbortTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+	return n, ctx.Err()
+}
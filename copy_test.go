@@ -0,0 +1,41 @@
+package ctxexec
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestCopyContext(t *testing.T) {
+	var dst bytes.Buffer
+	n, err := CopyContext(context.Background(), &dst, strings.NewReader("hello"))
+	if err != nil || n != 5 || dst.String() != "hello" {
+		t.Fatalf("unexpected copy %d %v %q", n, err, dst.String())
+	}
+}
+
+func TestCopyContext_Canceled(t *testing.T) {
+	pr, pw := io.Pipe()
+	go pw.Write([]byte("partial"))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	var dst bytes.Buffer
+	start := time.Now()
+	n, err := CopyContext(ctx, &dst, pr)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if n != 7 || dst.String() != "partial" {
+		t.Fatalf("expected the partial copy, got %d %q", n, dst.String())
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("expected the copy to abort promptly")
+	}
+	if _, err := pw.Write([]byte("x")); err != io.ErrClosedPipe {
+		t.Fatalf("expected the source to be closed, got %v", err)
+	}
+}
@@ -2,14 +2,22 @@
 package ctxexec
 
 import (
+	"io"
+	"log"
+	"log/slog"
 	"os"
 	"os/exec"
+	"slices"
+	"sync"
 	"syscall"
+	"time"
 
 	"golang.org/x/net/context"
 )
 
 // StopFunc is the function that terminates a command
+//
+// It should not call cmd.Wait, the process is reaped by the CtxCmd.
 type StopFunc func(ctx context.Context, cmd *exec.Cmd) error
 
 // CtxCmd wrapps the *exec.Cmd with a StopFunc
@@ -19,11 +27,262 @@ type CtxCmd struct {
 	// StopFunc is the function to call when stopping the command
 	StopFunc
 	*exec.Cmd // Cmd represents an external command being prepared or run
+
+	// Grace is the time the command is given to exit gracefully before it
+	// is killed, when stopped by Close, by ShutdownAll or by Wait once its
+	// run context is done, independently of that already expired context
+	Grace time.Duration
+
+	// StopSignals are sent in order to stop the command gracefully
+	StopSignals []os.Signal
+
+	// ProcessGroup starts the command in a new process group and sends the
+	// stop signals and the kill to the whole group, so the grandchildren
+	// of shell wrappers such as sh -c don't outlive the command. On Windows
+	// the descendants are always killed along with the command, using a
+	// job object.
+	ProcessGroup bool
+
+	// DieWithParent kills the command when the program dies, even if it
+	// crashes without stopping it. On linux the kernel sends SIGKILL, see
+	// the Pdeathsig caveats of syscall.SysProcAttr. On Windows the job
+	// object of the command, see ProcessGroup, kills its processes once
+	// the program or the command exits. On other systems a watchdog
+	// process polls the program every second.
+	DieWithParent bool
+
+	// Logger is the logger of the command, the package Logger when nil
+	Logger *log.Logger
+
+	// Slog receives structured records of the start, the stop signals, the
+	// kill and the exit of the command, whatever the context verbosity. The
+	// command is rendered as by String, with its secrets redacted.
+	Slog *slog.Logger
+
+	// Tracer opens a span around Run, see Tracer
+	Tracer Tracer
+
+	// Metrics collects the metrics of the command
+	Metrics *Metrics
+	metrics *Metrics // metrics is the Metrics the start was accounted for
+
+	profileCtx context.Context // profileCtx carries the profile labels of the run
+
+	// Labels are user-defined metadata, such as job=etl, attached
+	// to the log lines and trace events of the command
+	Labels map[string]string
+
+	// StartWhen are the conditions to satisfy before the command is started
+	StartWhen []Condition
+
+	// StartTimeout bounds the time spent in fork/exec and waiting for
+	// ReadyWhen, independently of the run context. Zero means no limit.
+	StartTimeout time.Duration
+
+	// ReadyWhen is the optional condition that tells the command is ready,
+	// such as FirstOutput. Commands depending on it with Ready wait for it.
+	ReadyWhen Condition
+
+	// ExportDeadline exports the earliest of the run context's deadline
+	// and the package-managed deadline to the command
+	// as DeadlineEnv and TimeoutEnv, so cooperative programs can shut
+	// themselves down before they are killed
+	ExportDeadline bool
+
+	// PropagateTrace exports the trace context of the run context, see
+	// TraceContext, to the command as TraceParentEnv and TraceStateEnv,
+	// so instrumented programs join the same distributed trace
+	PropagateTrace bool
+
+	// GracefulStopOK makes Wait return nil instead of an error when the
+	// context is done and the command exits gracefully in response, with
+	// a zero status or terminated by one of the signals sent to stop it.
+	// The command is given Grace to exit before it is killed.
+	GracefulStopOK bool
+
+	// RichErrors makes Wait return an *Error wrapping the *exec.ExitError,
+	// with the command line and the tail of the standard error, when the
+	// command fails
+	RichErrors bool
+
+	// SuccessExitCodes are non-zero exit codes that are not reported as
+	// errors, such as grep's 1. The actual code is still available in
+	// the process state.
+	SuccessExitCodes []int
+
+	// MaxRuntime limits how long the command may run, even when the run
+	// context has no deadline. It sets the package-managed deadline when
+	// the command starts. Zero means no limit.
+	MaxRuntime time.Duration
+
+	// MaxMemory is the resident memory, in bytes, over which the command
+	// is stopped, as by Stop given Grace, with ErrMemoryLimitExceeded as
+	// its StopCause. It is sampled every WatchInterval, zero means no limit.
+	MaxMemory uint64
+
+	// MaxMemoryTree counts the resident memory of the process descendants
+	// toward MaxMemory
+	MaxMemoryTree bool
+
+	// MaxDirSize is the total size, in bytes, of the files in the working
+	// directory Dir over which the command is stopped, as by Stop given
+	// Grace, with ErrDiskQuotaExceeded as its StopCause. It is sampled
+	// every WatchInterval, zero means no limit.
+	MaxDirSize uint64
+
+	// Monitor samples the resource usage of the process and enforces its
+	// CPU limit, see Monitor, the memory is limited by MaxMemory
+	Monitor *Monitor
+
+	// Cgroup, on Linux, confines the process to a transient cgroup v2 with
+	// hard resource limits, created under CgroupParent before the process
+	// is started and removed once it exited
+	Cgroup *CgroupLimits
+
+	// Rlimits are the resource limits of the process. They are set, like
+	// Nice, IOPriority, CPUAffinity and OOMScoreAdj, between fork and exec
+	// by re-executing the program as a shim, on unix, which requires calling
+	// ShimMain first in main. Start returns once they are set.
+	Rlimits []Rlimit
+
+	// Nice is the niceness of the process, zero leaves the one of the program
+	Nice int
+
+	// IOPriority, on Linux, is the I/O scheduling priority of the process,
+	// the zero value leaves the one of the program. The command fails to
+	// start with one set on other platforms.
+	IOPriority IOPriority
+
+	// CPUAffinity, on Linux, are the CPUs the process is pinned to before
+	// the command is executed, such as to keep it away from the cores
+	// serving requests. The processes it creates inherit it. The command
+	// fails to start with one set on other platforms.
+	CPUAffinity []int
+
+	// OOMScoreAdj, on Linux, is written to the oom_score_adj of the process
+	// before the command is executed, from -1000 to 1000. A positive score
+	// makes it a preferred victim of the OOM killer over the program, zero
+	// leaves the inherited one. The command fails to start with one set on
+	// other platforms.
+	OOMScoreAdj int
+
+	// DirMode, when set, is the permissions Dir is created with, along with
+	// its parents, if it is missing when the command starts. Otherwise a
+	// missing Dir is reported as an *ArgError.
+	DirMode os.FileMode
+
+	// Policy is consulted before the command is executed, DefaultPolicy
+	// is used when nil. Commands admitted with a release function are
+	// reaped in the background, use Wait rather than Cmd.Wait on them.
+	Policy Policy
+
+	// Lock, when set, is acquired with LockName before the command is
+	// started and released once it exited, so a single instance runs at a
+	// time across processes or hosts. Commands with a Lock are reaped in
+	// the background, use Wait rather than Cmd.Wait on them.
+	Lock Lock
+
+	// LockName is the name of the Lock, the base name of the command's
+	// Path when empty
+	LockName string
+
+	// RegisterShutdown registers the command, while it runs, to be stopped
+	// by ShutdownAll. Registered commands are reaped in the background, use
+	// Wait rather than Cmd.Wait on them.
+	RegisterShutdown bool
+
+	// StdoutLines and StderrLines, when set, are called with each line of
+	// the standard output and standard error, without the line ending,
+	// while the command runs, in addition to Stdout and Stderr. They are
+	// called from the goroutines copying the output, so slow callbacks slow
+	// down the command. A last partial line is passed once the process
+	// exited, commands with line callbacks are reaped in the background,
+	// use Wait rather than Cmd.Wait on them.
+	StdoutLines, StderrLines func(line string)
+
+	// PTY runs the command attached to a new pseudo-terminal, available
+	// with Terminal, so tools checking isatty keep their interactive
+	// behavior and colors. Stdin and Stdout, when set, are copied to and
+	// from the terminal, which merges the standard output and error, Stderr
+	// is ignored. The terminal is closed once the process exited and its
+	// output was copied to Stdout, or after PipeCloseTimeout when
+	// grandchildren hold it open. When Stdout is nil the caller reads it
+	// and it is closed PipeCloseTimeout after the process exited. Commands
+	// with PTY are reaped in the background, use Wait rather than Cmd.Wait
+	// on them. Only supported on linux.
+	PTY bool
+
+	// ForwardSignals are relayed to the process when the program receives
+	// them, until it exits, so wrappers behave like the wrapped program
+	// under Ctrl-C. The program itself doesn't terminate on them meanwhile.
+	// Commands forwarding signals are reaped in the background, use Wait
+	// rather than Cmd.Wait on them.
+	ForwardSignals []os.Signal
+
+	// Interceptors rewrite the command just before it is executed, after
+	// the ones registered with AddInterceptor. The Policy is consulted on
+	// the command as it was before they ran and, if they changed its path
+	// or arguments, on the rewritten command too.
+	Interceptors []Interceptor
+
+	// Before are commands run in order before the command is started, the
+	// command isn't started if one of them fails
+	Before []*CtxCmd
+
+	// After are commands run in order once the command exited, even when it
+	// was stopped or killed, or once it failed to start after the Before
+	// commands ran. Commands with After are reaped in the background,
+	// use Wait rather than Cmd.Wait on them.
+	//
+	// Before and After commands are bounded by their MaxRuntime, or by
+	// DefaultHookTimeout when they have none.
+	After []*CtxCmd
+
+	mu              sync.Mutex
+	started         chan struct{} // started is closed once the process has started
+	timeline        timeline
+	watchdog        watchdog
+	reaper          sync.Once
+	doneOnce        sync.Once
+	done            chan struct{}               // done is closed once the process is started and reaped, see Done
+	onExit          []func()                    // onExit are called once the process is reaped
+	onStartFailure  []func()                    // onStartFailure are called if the process fails to start, once its output is no longer written
+	closeAfterStart []io.Closer                 // closeAfterStart are the parent's ends of the pipes passed to the process
+	outputFiles     [2]string                   // outputFiles are the paths of the Builder's StdoutFile and StderrFile
+	cleanups        []func(ctx context.Context) // cleanups are called in reverse order once the process is reaped
+	cleanedUp       bool                        // cleanedUp is set once the cleanups were called, later ones are called right away
+	exited          chan struct{}               // exited is closed once the process is reaped
+	waitErr         error                       // waitErr is the error returned by Cmd.Wait
+	sys             sysProc                     // sys is the platform state of the process
+	tail            [2]*RingBuffer              // tail are the stdout and stderr captured with CaptureTail
+	terminal        *Terminal                   // terminal is the pseudo-terminal of the command, see PTY
+	compressed      *CompressedOutput           // compressed describes the output compressed with CompressOutput
+	cause           error                       // cause is the reason the package stopped the command
+	adopted         bool                        // adopted is set for processes started by another program, see Adopt
+	reaping         bool                        // reaping is set once the process is reaped in the background
+	reaped          bool                        // reaped is set once the process is reaped and the exit functions ran
+	waited          bool                        // waited is set once Wait returned
+	status          Status                      // status is the lifecycle state, see Status
 }
 
 // New returns a new CtxCmd for the *exec.Cmd with a default StopFunc
-func New(cmd *exec.Cmd) *CtxCmd {
-	return &CtxCmd{Cmd: cmd, StopFunc: stopFunc}
+// and the Options set by SetDefaults, overridden by the binary's Preset
+// then by the functional options
+func New(cmd *exec.Cmd, opts ...Option) *CtxCmd {
+	o := Defaults()
+	c := &CtxCmd{
+		Cmd:          cmd,
+		StopFunc:     stopFunc,
+		Grace:        o.Grace,
+		StopSignals:  o.StopSignals,
+		ProcessGroup: o.ProcessGroup,
+		Logger:       o.Logger,
+	}
+	c.applyPreset()
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // Run starts the specified command and waits for it to complete.
@@ -87,10 +346,19 @@ func Start(cmd *exec.Cmd) error {
 // If the command fails to run or doesn't complete successfully, the
 // error is of type *exec.ExitError, context.DeadlineExceeded,
 // context.Canceled. Other error types may be returned for I/O problems.
-func (c *CtxCmd) Run(ctx context.Context) error {
-	if err := c.Start(); err != nil {
+func (c *CtxCmd) Run(ctx context.Context) (err error) {
+	ctx = withCmd(ctx, c)
+	if c.Tracer != nil {
+		var span Span
+		ctx, span = c.Tracer.StartSpan(ctx, c.spanName())
+		defer func() { c.endSpan(span, err) }()
+	}
+	logf(ctx, Debug, "run %q", c.Args)
+	if err := c.StartContext(ctx); err != nil {
+		logf(ctx, Errors, "start %q: %v", c.Args, err)
 		return err
 	}
+	logf(ctx, Debug, "started %q, pid %d", c.Args, pidOf(c.Cmd))
 	return c.Wait(ctx)
 }
 
@@ -99,7 +367,195 @@ func (c *CtxCmd) Run(ctx context.Context) error {
 // The Wait method will return the exit code and release associated resources
 // once the command exits.
 func (c *CtxCmd) Start() error {
-	return c.Cmd.Start()
+	return c.StartContext(context.Background())
+}
+
+// StartContext waits for the StartWhen conditions using the context, then
+// starts the specified command but does not wait for it to complete.
+//
+// The returned error is the context's error if it is done before the
+// conditions are satisfied, the Lock acquired or a slot is available under
+// SetMaxConcurrent, an *ArgError if the command is malformed as reported
+// by Validate, ErrLocked if the Lock is held elsewhere and doesn't wait, a
+// *PolicyError if the Policy rejected the command, or ErrStartTimeout if
+// the command didn't start and become ready within StartTimeout. It is
+// ErrAlreadyStarted or ErrSpent if the command was already started.
+func (c *CtxCmd) StartContext(ctx context.Context) (err error) {
+	if err := c.checkStart(); err != nil {
+		return err
+	}
+	c.setStatus(StatusStarting)
+	defer func() {
+		if err != nil {
+			c.transition(StatusStarting, StatusFailed)
+			c.timeline.recordStartFailure(err)
+		}
+	}()
+	c.setProfileLabels(ctx)
+	// releases free what was reserved for the process, in reverse order,
+	// once it exited or if it fails to start
+	var releases []func()
+	reserve := func(release func()) {
+		if release != nil {
+			releases = append([]func(){release}, releases...)
+		}
+	}
+	var execd bool // execd is set once exec owns the start failure
+	defer func() {
+		if err != nil {
+			for _, f := range releases {
+				f()
+			}
+		}
+		if err != nil && !execd {
+			for _, f := range c.closeAfterStart {
+				f.Close()
+			}
+			c.startFailed()
+		}
+	}()
+	for _, cond := range c.StartWhen {
+		if err := cond(ctx); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	var startDeadline time.Time
+	if c.StartTimeout > 0 {
+		startDeadline = now.Add(c.StartTimeout)
+	}
+	if c.MaxRuntime > 0 {
+		c.SetDeadline(now.Add(c.MaxRuntime))
+	}
+	if c.ExportDeadline {
+		c.exportDeadline(ctx)
+	}
+	if c.PropagateTrace {
+		c.propagateTrace(ctx)
+	}
+	unlock, err := c.lock(ctx)
+	if err != nil {
+		return err
+	}
+	reserve(unlock)
+	// the Policy is consulted before the interceptors rewrite the command,
+	// then on the rewritten command
+	path, args := c.Path, append([]string(nil), c.Args...)
+	release, err := c.admit()
+	if err != nil {
+		logf(ctx, Errors, "%v", err)
+		return err
+	}
+	reserve(release)
+	slot, err := global.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	reserve(slot)
+	if err := c.intercept(); err != nil {
+		return err
+	}
+	if c.Path != path || !slices.Equal(c.Args, args) {
+		release, err := c.admit()
+		if err != nil {
+			logf(ctx, Errors, "%v", err)
+			return err
+		}
+		reserve(release)
+	}
+	if err := c.createDir(); err != nil {
+		return err
+	}
+	if err := Validate(c.Cmd); err != nil {
+		return err
+	}
+	c.prepareProcess()
+	// the After commands undo what the Before ones did, even if the command
+	// fails to start
+	if len(c.After) > 0 {
+		reserve(c.runAfter)
+	}
+	if err := c.runBefore(ctx); err != nil {
+		return err
+	}
+	if err := c.openOutputFiles(); err != nil {
+		return err
+	}
+	if c.RichErrors {
+		c.captureStderr()
+	}
+	if c.StdoutLines != nil || c.StderrLines != nil {
+		c.attachLineFuncs()
+	}
+	if c.PTY {
+		closeTerminal, err := c.openTerminal()
+		if err != nil {
+			return err
+		}
+		reserve(closeTerminal)
+	}
+	if c.Cgroup != nil {
+		leaveCgroup, err := c.joinCgroup()
+		if err != nil {
+			return err
+		}
+		reserve(leaveCgroup)
+	}
+	if err := c.shim(); err != nil {
+		return err
+	}
+	c.timeline.watchOutputs(c.Cmd)
+	execd = true
+	if err := c.exec(startDeadline); err != nil {
+		return err
+	}
+	c.attachProcess(ctx)
+	c.setStatus(StatusRunning)
+	c.timeline.recordStarted()
+	c.logAttrs(ctx, slog.LevelInfo, "start", slog.String("command", c.String()))
+	if c.Metrics != nil {
+		c.Metrics.start(c)
+		c.metrics = c.Metrics
+	}
+	if c.MaxMemory > 0 {
+		go c.watchMemory(ctx)
+	}
+	if c.MaxDirSize > 0 {
+		go c.watchDir(ctx)
+	}
+	if c.Monitor != nil {
+		go c.monitor(ctx, c.Monitor)
+	}
+	// the exit functions now own the releases, reaping the process runs them
+	c.onExit, releases = append(c.onExit, releases...), nil
+	if c.RegisterShutdown {
+		c.register()
+	}
+	if len(c.ForwardSignals) > 0 {
+		c.forwardSignals(ctx)
+	}
+	c.mu.Lock()
+	cleanups := len(c.cleanups)
+	c.mu.Unlock()
+	if len(c.onExit) > 0 || cleanups > 0 {
+		// reap eagerly, so the exit functions run even if the command is never waited on
+		c.reap()
+	}
+	if err := c.awaitReady(ctx, startDeadline); err != nil {
+		return err
+	}
+	close(c.startedChan())
+	return nil
+}
+
+// startedChan returns the channel closed once the process has started and is ready
+func (c *CtxCmd) startedChan() chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.started == nil {
+		c.started = make(chan struct{})
+	}
+	return c.started
 }
 
 // Stop terminates the execution when the command is running.
@@ -110,7 +566,23 @@ func (c *CtxCmd) Start() error {
 // It gracefully waits for the command to finish execution before killing
 // it after a timeout.
 func (c *CtxCmd) Stop(ctx context.Context) error {
-	return c.StopFunc(ctx, c.Cmd)
+	c.transition(StatusRunning, StatusStopping)
+	return c.StopFunc(withCmd(ctx, c), c.Cmd)
+}
+
+// Close gracefully stops the command, killing it if it hasn't exited within
+// Grace, and waits for the process to be reaped. It makes CtxCmd an
+// io.Closer so it can be used in cleanup stacks and defer chains.
+//
+// The returned error is the error returned by Stop.
+func (c *CtxCmd) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.grace())
+	defer cancel()
+	err := c.Stop(ctx)
+	if c.Cmd.Process != nil {
+		<-c.reap()
+	}
+	return err
 }
 
 // stopFunc is the default function used for terminating the command exectution
@@ -120,21 +592,88 @@ func stopFunc(ctx context.Context, cmd *exec.Cmd) error {
 		return nil
 	}
 	// try graceful termination first
-	cmd.Process.Signal(os.Interrupt)
-	cmd.Process.Signal(syscall.SIGTERM)
-	// wait for process to finish terminating, kill when context is cancelled
+	for _, sig := range stopSignals(ctx) {
+		signal(ctx, cmd, sig)
+	}
+	return awaitExit(ctx, cmd)
+}
+
+// signal sends the signal to the process, recording and logging it
+func signal(ctx context.Context, cmd *exec.Cmd, sig os.Signal) error {
+	if err := sendSignal(ctx, cmd, sig); err != nil {
+		logf(ctx, Errors, "pid %d: signal %v: %v", cmd.Process.Pid, sig, err)
+		logAttrsCtx(ctx, slog.LevelWarn, "signal", slog.String("signal", sig.String()), slog.Any("error", err))
+		return err
+	}
+	record(ctx, EventSignal, sig)
+	logf(ctx, Debug, "pid %d: sent %v", cmd.Process.Pid, sig)
+	logAttrsCtx(ctx, slog.LevelInfo, "signal", slog.String("signal", sig.String()))
+	return nil
+}
+
+// Signal sends the signal to the running process, or to its group with
+// ProcessGroup, such as to make it reload its configuration. It returns
+// ErrNotRunning if the process hasn't started or exited.
+//
+// Unlike the signals sent by the StopFunc, it isn't recorded in the Timeline.
+func (c *CtxCmd) Signal(sig os.Signal) error {
+	switch c.Status() {
+	case StatusRunning, StatusStopping:
+	default:
+		return ErrNotRunning
+	}
+	ctx := withCmd(context.Background(), c)
+	if err := sendSignal(ctx, c.Cmd, sig); err != nil {
+		if err == os.ErrProcessDone || err == syscall.ESRCH {
+			return ErrNotRunning
+		}
+		return err
+	}
+	logf(ctx, Debug, "pid %d: sent %v", c.Process.Pid, sig)
+	return nil
+}
+
+// awaitExit waits for the process to finish terminating, killing it when
+// the context is done
+func awaitExit(ctx context.Context, cmd *exec.Cmd) error {
+	exited, waitErr := exited(ctx, cmd)
 	select {
+	case <-exited:
+		return waitErr()
 	case <-ctx.Done():
-		cmd.Process.Kill()
+		kill(ctx, cmd)
 		return ctx.Err()
-	default:
-		if err := cmd.Wait(); err != nil {
-			return err
-		}
-		return nil
 	}
 }
 
+// kill kills the process, recording and logging it
+func kill(ctx context.Context, cmd *exec.Cmd) {
+	if err := killProcess(ctx, cmd); err != nil {
+		logf(ctx, Errors, "pid %d: kill: %v", cmd.Process.Pid, err)
+		logAttrsCtx(ctx, slog.LevelError, "kill", slog.Any("error", err))
+		return
+	}
+	record(ctx, EventKill, nil)
+	logf(ctx, Debug, "pid %d: killed", cmd.Process.Pid)
+	logAttrsCtx(ctx, slog.LevelInfo, "kill")
+}
+
+// exited returns a channel closed once the process exited and a function
+// returning the wait error. The process is reaped by the CtxCmd carried by
+// the context, if any.
+func exited(ctx context.Context, cmd *exec.Cmd) (<-chan struct{}, func() error) {
+	if c, ok := ctx.Value(cmdKey{}).(*CtxCmd); ok && c.Cmd == cmd {
+		return c.reap(), c.exitError
+	}
+	done := make(chan struct{})
+	var err error
+	go func() {
+		err = cmd.Wait()
+		close(done)
+	}()
+	return done, func() error { <-done; return err }
+}
+
 // Wait waits for the command to exit.
 // It must have been started by Start.
 //
@@ -150,16 +689,153 @@ func stopFunc(ctx context.Context, cmd *exec.Cmd) error {
 // copying from c.Stdin into the process's standard input
 // to complete.
 //
+// Wait returns as soon as the process exits. If the context is done
+// first, the command is stopped, given Grace, and Wait returns once it
+// exited. The command is also stopped when its package-managed deadline,
+// set with SetDeadline, passes. Once Wait returned, it returns
+// ErrAlreadyWaited.
+//
 // Wait releases any resources associated with the Cmd.
 func (c *CtxCmd) Wait(ctx context.Context) error {
-	<-ctx.Done()
-	c.Stop(ctx)
-	if err := c.Cmd.Wait(); err != nil { // wait for the process to be killed
+	if err := c.checkWait(); err != nil {
+		return err
+	}
+	defer c.setWaited()
+	ctx, release := c.watchdog.watch(ctx)
+	defer release()
+	ctx = withCmd(ctx, c)
+	select {
+	case <-c.reap():
+		if err := c.exitError(); err != nil {
+			logf(ctx, Debug, "pid %d: wait: %v", pidOf(c.Cmd), err)
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+	}
+	logf(ctx, Debug, "pid %d: %v, stopping", pidOf(c.Cmd), ctx.Err())
+	sctx, cancel := context.WithTimeout(detach(ctx), c.grace())
+	c.Stop(sctx)
+	cancel()
+	<-c.reap() // wait for the process to be killed
+	if c.GracefulStopOK && c.stoppedGracefully() {
+		logf(ctx, Debug, "pid %d: stopped gracefully", pidOf(c.Cmd))
+		return nil
+	}
+	if err := c.exitError(); err != nil {
+		logf(ctx, Debug, "pid %d: wait: %v", pidOf(c.Cmd), err)
 		return err
 	}
 	return ctx.Err()
 }
 
+// WaitState waits for the command to exit like Wait and returns its
+// process state along with the error, so the exit code, signal and
+// resource usage are available in one call.
+//
+// The returned state is nil if the process didn't start.
+func (c *CtxCmd) WaitState(ctx context.Context) (*os.ProcessState, error) {
+	err := c.Wait(ctx)
+	return c.Cmd.ProcessState, err
+}
+
+// Unwrap returns the underlying *exec.Cmd, the same as the embedded Cmd,
+// for code handling wrapped commands generically.
+//
+// Its Process and ProcessState are also available on the CtxCmd itself,
+// Cmd.Wait must not be called on commands reaped in the background.
+func (c *CtxCmd) Unwrap() *exec.Cmd {
+	return c.Cmd
+}
+
+// pidOf returns the pid of the command's process, or -1 if it hasn't started
+func pidOf(cmd *exec.Cmd) int {
+	if cmd == nil || cmd.Process == nil {
+		return -1
+	}
+	return cmd.Process.Pid
+}
+
+// reap waits for the process in the background, only once, and returns
+// the channel closed once it exited and released its resources
+func (c *CtxCmd) reap() <-chan struct{} {
+	c.reaper.Do(func() {
+		c.exited = make(chan struct{})
+		c.mu.Lock()
+		c.reaping = true
+		c.mu.Unlock()
+		go func() {
+			c.labelGoroutine()
+			if c.adopted {
+				c.waitErr = waitAdopted(c.Process)
+			} else {
+				c.waitErr = c.Cmd.Wait()
+			}
+			c.detachProcess()
+			if c.stopped() {
+				c.timeline.recordExit()
+			}
+			c.logExit()
+			if c.metrics != nil {
+				c.metrics.exit(c)
+			}
+			for _, f := range c.onExit {
+				f()
+			}
+			c.runCleanups()
+			status := StatusExited
+			if c.exitError() != nil {
+				status = StatusFailed
+			}
+			c.mu.Lock()
+			c.reaped = true
+			c.status = status
+			c.mu.Unlock()
+			c.timeline.closeSubscribers()
+			close(c.exited)
+		}()
+	})
+	return c.exited
+}
+
+// exitError returns the error returned by reaping the process, or nil when
+// the process exited with one of the SuccessExitCodes. Exit errors are
+// wrapped in an *Error with RichErrors.
+func (c *CtxCmd) exitError() error {
+	if ee, ok := c.waitErr.(*exec.ExitError); ok {
+		for _, code := range c.SuccessExitCodes {
+			if ee.ExitCode() == code {
+				return nil
+			}
+		}
+		if c.RichErrors {
+			return c.richError(ee)
+		}
+	}
+	return c.waitErr
+}
+
+// stoppedGracefully returns true if the process exited without being killed,
+// with a zero status or terminated by one of the signals sent to stop it
+func (c *CtxCmd) stoppedGracefully() bool {
+	if !c.stopped() {
+		return false
+	}
+	sent := map[os.Signal]bool{}
+	for _, e := range c.Timeline() {
+		switch e.Kind {
+		case EventKill:
+			return false
+		case EventSignal:
+			sent[e.Signal] = true
+		}
+	}
+	if ws, ok := c.Cmd.ProcessState.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return sent[ws.Signal()]
+	}
+	return c.Cmd.ProcessState.Success()
+}
+
 // stopped returns true if the process stopped and created a process state
 func (c *CtxCmd) stopped() bool {
 	return c.Cmd.ProcessState != nil // ProcessState is created only after the process stop running
@@ -1,6 +1,8 @@
 package ctxexec
 
 import (
+	"io"
+	"os"
 	"os/exec"
 	"testing"
 	"time"
@@ -8,6 +10,11 @@ import (
 	"golang.org/x/net/context"
 )
 
+func TestMain(m *testing.M) {
+	ShimMain()
+	os.Exit(m.Run())
+}
+
 func TestWait(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
 	defer cancel()
@@ -20,6 +27,20 @@ func TestWait(t *testing.T) {
 	}
 }
 
+func TestWait_ExitsEarly(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	c := New(exec.Command("true"))
+	c.Start()
+	start := time.Now()
+	if err := c.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("expected Wait to return once the process exited, took %v", d)
+	}
+}
+
 func TestWait_Kill(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*1)
 	defer cancel()
@@ -32,6 +53,67 @@ func TestWait_Kill(t *testing.T) {
 	}
 }
 
+func TestWait_GracefulStopOK(t *testing.T) {
+	for _, run := range []string{
+		`trap "exit 0" SIGINT SIGTERM; while true; do sleep 0.1; done`,
+		`exec sleep 5`,
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+		c := New(exec.Command("bash", "-c", run))
+		c.GracefulStopOK = true
+		c.Start()
+		time.Sleep(time.Millisecond * 100)
+		cancel()
+		if err := c.Wait(ctx); err != nil {
+			t.Fatalf("expected graceful stop to succeed for %q, got %v", run, err)
+		}
+	}
+}
+
+func TestWait_Grace(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+	defer cancel()
+	run := `trap "sleep 0.3; exit 0" SIGINT SIGTERM; while true; do sleep 0.1; done`
+	c := New(exec.Command("bash", "-c", run), WithGrace(time.Second*2))
+	c.Start()
+	c.Wait(ctx)
+	if !c.Cmd.ProcessState.Success() {
+		t.Fatalf("expected the command to exit gracefully within the grace, got %v", c.Cmd.ProcessState)
+	}
+	for _, e := range c.Timeline() {
+		if e.Kind == EventKill {
+			t.Fatal("expected no kill")
+		}
+	}
+}
+
+func TestWaitState(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	c := New(exec.Command("bash", "-c", "exit 3"))
+	c.Start()
+	state, err := c.WaitState(ctx)
+	if err == nil {
+		t.Fatal("expected an exit error")
+	}
+	if state == nil || state.ExitCode() != 3 {
+		t.Fatalf("expected exit code 3, got %+v", state)
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	cmd := exec.Command("true")
+	c := New(cmd)
+	if c.Unwrap() != cmd {
+		t.Fatal("expected the wrapped command")
+	}
+	c.Start()
+	<-c.reap()
+	if c.Process != cmd.Process || c.ProcessState != cmd.ProcessState || c.ProcessState == nil {
+		t.Fatal("expected the process and its state of the wrapped command")
+	}
+}
+
 func TestStop(t *testing.T) {
 	run := `trap "echo intr; exit 0" SIGINT SIGTERM; while true; do echo running; sleep 1; done`
 	c := New(exec.Command("bash", "-c", run))
@@ -53,3 +135,29 @@ func TestStop_NoStart(t *testing.T) {
 		t.Fatalf("process failed to exit successfully. %+v", c.Cmd.ProcessState)
 	}
 }
+
+func TestClose(t *testing.T) {
+	run := `trap "echo intr; exit 0" SIGINT SIGTERM; while true; do echo running; sleep 1; done`
+	var c io.Closer = New(exec.Command("bash", "-c", run))
+	c.(*CtxCmd).Start()
+	time.Sleep(time.Millisecond * 200)
+	if err := c.Close(); err != nil {
+		t.Fatalf("expected graceful close, got %v", err)
+	}
+	if !c.(*CtxCmd).Cmd.ProcessState.Success() {
+		t.Fatalf("process failed to exit successfully. %+v", c.(*CtxCmd).Cmd.ProcessState)
+	}
+}
+
+func TestClose_Kill(t *testing.T) {
+	c := New(exec.Command("bash", "-c", `trap "" SIGINT SIGTERM; sleep 5`))
+	c.Grace = time.Millisecond * 200
+	c.Start()
+	time.Sleep(time.Millisecond * 100)
+	if err := c.Close(); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if !c.stopped() {
+		t.Fatal("expected stop")
+	}
+}
@@ -0,0 +1,149 @@
+package ctxexec
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Task is a command of a DAG run once its dependencies succeeded
+type Task struct {
+	Name    string        // Name identifies the task in the results
+	Cmd     *CtxCmd       // Cmd is the command of the task
+	Timeout time.Duration // Timeout bounds the context of the task, zero means no limit
+
+	deps []*Task
+}
+
+// NewTask returns a new Task running the command
+func NewTask(name string, cmd *CtxCmd) *Task {
+	return &Task{Name: name, Cmd: cmd}
+}
+
+// After makes the task depend on the other tasks and returns it
+func (t *Task) After(deps ...*Task) *Task {
+	t.deps = append(t.deps, deps...)
+	return t
+}
+
+// TaskError is returned by RunDAG when a task fails
+type TaskError struct {
+	Task string // Task is the name of the first task that failed
+	Err  error  // Err is the error of the task
+}
+
+func (e *TaskError) Error() string {
+	return fmt.Sprintf("ctxexec: task %q failed: %v", e.Task, e.Err)
+}
+
+// Unwrap returns the error of the task, for errors.As and errors.Is
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// RunDAG runs the tasks, and their dependencies, each once all its
+// dependencies succeeded, with RunResult and a context of its own derived
+// from ctx. Independent tasks run concurrently.
+//
+// Once a task fails, the running tasks are stopped, the pending ones are
+// skipped and the failure is returned as a *TaskError. It returns the
+// Results by task name, tasks that didn't run have none.
+func RunDAG(ctx context.Context, tasks ...*Task) (map[string]*Result, error) {
+	order, err := topoSort(tasks)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		mu      sync.Mutex
+		results = map[string]*Result{}
+		first   error
+		wg      sync.WaitGroup
+	)
+	done := map[*Task]chan struct{}{}
+	failed := map[*Task]bool{}
+	for _, t := range order {
+		done[t] = make(chan struct{})
+	}
+	for _, t := range order {
+		wg.Add(1)
+		go func(t *Task) {
+			defer wg.Done()
+			defer close(done[t])
+			for _, dep := range t.deps {
+				<-done[dep]
+			}
+			mu.Lock()
+			skip := ctx.Err() != nil
+			for _, dep := range t.deps {
+				skip = skip || failed[dep]
+			}
+			mu.Unlock()
+			if skip {
+				mu.Lock()
+				failed[t] = true
+				mu.Unlock()
+				return
+			}
+			tctx, tcancel := ctx, context.CancelFunc(func() {})
+			if t.Timeout > 0 {
+				tctx, tcancel = context.WithTimeout(ctx, t.Timeout)
+			}
+			r, err := t.Cmd.RunResult(tctx)
+			tcancel()
+			mu.Lock()
+			results[t.Name] = r
+			if err != nil {
+				failed[t] = true
+				if first == nil {
+					first = &TaskError{Task: t.Name, Err: err}
+					cancel()
+				}
+			}
+			mu.Unlock()
+		}(t)
+	}
+	wg.Wait()
+	if first == nil {
+		first = ctx.Err()
+	}
+	return results, first
+}
+
+// topoSort returns the tasks and their dependencies, each after its
+// dependencies, or an error if they depend on each other
+func topoSort(tasks []*Task) ([]*Task, error) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[*Task]int{}
+	var order []*Task
+	var visit func(t *Task) error
+	visit = func(t *Task) error {
+		switch state[t] {
+		case visiting:
+			return fmt.Errorf("ctxexec: dependency cycle through task %q", t.Name)
+		case visited:
+			return nil
+		}
+		state[t] = visiting
+		for _, dep := range t.deps {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[t] = visited
+		order = append(order, t)
+		return nil
+	}
+	for _, t := range tasks {
+		if err := visit(t); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
@@ -0,0 +1,60 @@
+package ctxexec
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestRunDAG(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ctxexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	log := filepath.Join(dir, "log")
+	task := func(name string) *Task {
+		return NewTask(name, New(exec.Command("bash", "-c", "sleep 0.05; echo "+name+" >> "+log)))
+	}
+	fetch, build := task("fetch"), task("build")
+	lint := task("lint").After(fetch)
+	deploy := task("deploy").After(build.After(fetch), lint)
+	results, err := RunDAG(context.Background(), deploy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("expected the dependencies to run, got %v", results)
+	}
+	out, _ := ioutil.ReadFile(log)
+	if s := string(out); s != "fetch\nbuild\nlint\ndeploy\n" && s != "fetch\nlint\nbuild\ndeploy\n" {
+		t.Fatalf("expected a topological order, got %q", s)
+	}
+}
+
+func TestRunDAG_Failure(t *testing.T) {
+	a := NewTask("a", New(exec.Command("false")))
+	b := NewTask("b", New(exec.Command("true"))).After(a)
+	c := NewTask("c", New(exec.Command("true")))
+	results, err := RunDAG(context.Background(), b, c)
+	if te, ok := err.(*TaskError); !ok || te.Task != "a" {
+		t.Fatalf("expected task a to fail, got %v", err)
+	}
+	if ee := (*exec.ExitError)(nil); !errors.As(err, &ee) {
+		t.Fatalf("expected the exit error of the task, got %v", err)
+	}
+	if results["a"] == nil || results["b"] != nil {
+		t.Fatalf("expected b to be skipped, got %v", results)
+	}
+
+	x, y := NewTask("x", New(exec.Command("true"))), NewTask("y", New(exec.Command("true")))
+	x.After(y.After(x))
+	if _, err := RunDAG(context.Background(), x); err == nil {
+		t.Fatal("expected the cycle to be rejected")
+	}
+}
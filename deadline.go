@@ -0,0 +1,172 @@
+package ctxexec
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// watchdog is the package-managed deadline of a command, unlike the
+// deadline of the run context it can be extended while the command runs
+type watchdog struct {
+	mu       sync.Mutex
+	deadline time.Time
+	timer    *time.Timer
+	expired  chan struct{} // expired is closed when the deadline passes
+}
+
+func (w *watchdog) expiredChan() chan struct{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.expiredLocked()
+}
+
+func (w *watchdog) expiredLocked() chan struct{} {
+	if w.expired == nil {
+		w.expired = make(chan struct{})
+	}
+	return w.expired
+}
+
+// set arms the watchdog to expire at t, a zero t disarms it. It is a no-op
+// once the watchdog has expired.
+func (w *watchdog) set(t time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	expired := w.expiredLocked()
+	select {
+	case <-expired:
+		return
+	default:
+	}
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.deadline = t
+	if t.IsZero() {
+		return
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(t.Sub(time.Now()), func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		// a timer replaced by a later set, even one re-arming the same
+		// deadline, doesn't act
+		if w.timer != timer {
+			return
+		}
+		w.timer = nil
+		close(expired)
+	})
+	w.timer = timer
+}
+
+func (w *watchdog) get() (time.Time, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.deadline, !w.deadline.IsZero()
+}
+
+// SetDeadline sets the package-managed deadline of the command, Wait stops
+// the command when it passes as if the run context's deadline was exceeded.
+// A zero t clears the deadline.
+func (c *CtxCmd) SetDeadline(t time.Time) {
+	c.watchdog.set(t)
+}
+
+// Deadline returns the package-managed deadline of the command, ok is
+// false when none is set
+func (c *CtxCmd) Deadline() (deadline time.Time, ok bool) {
+	return c.watchdog.get()
+}
+
+// Extend pushes the package-managed deadline out by d, so supervisors can
+// grant more time to a command that reports progress. It returns false
+// when no deadline is set or it already passed.
+func (c *CtxCmd) Extend(d time.Duration) bool {
+	c.watchdog.mu.Lock()
+	deadline := c.watchdog.deadline
+	expired := c.watchdog.expiredLocked()
+	c.watchdog.mu.Unlock()
+	select {
+	case <-expired:
+		return false
+	default:
+	}
+	if deadline.IsZero() {
+		return false
+	}
+	c.watchdog.set(deadline.Add(d))
+	return true
+}
+
+// watchdogCtx is a context done when either its parent is done
+// or the watchdog expires
+type watchdogCtx struct {
+	context.Context
+	w    *watchdog
+	done chan struct{}
+	mu   sync.Mutex
+	err  error
+}
+
+// watch returns a copy of the parent context that is also done when the
+// watchdog expires, with context.DeadlineExceeded as its error. The returned
+// function releases the resources associated with it.
+func (w *watchdog) watch(parent context.Context) (context.Context, func()) {
+	ctx := &watchdogCtx{Context: parent, w: w, done: make(chan struct{})}
+	release := make(chan struct{})
+	go func() {
+		select {
+		case <-parent.Done():
+			ctx.cancel(parent.Err())
+		case <-w.expiredChan():
+			ctx.cancel(context.DeadlineExceeded)
+		case <-release:
+		}
+	}()
+	var once sync.Once
+	return ctx, func() { once.Do(func() { close(release) }) }
+}
+
+func (c *watchdogCtx) cancel(err error) {
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+	close(c.done)
+}
+
+func (c *watchdogCtx) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *watchdogCtx) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *watchdogCtx) Deadline() (time.Time, bool) {
+	deadline, ok := c.Context.Deadline()
+	if d, wok := c.w.get(); wok && (!ok || d.Before(deadline)) {
+		return d, true
+	}
+	return deadline, ok
+}
+
+// detachedCtx carries the values of its parent but is never done
+type detachedCtx struct {
+	context.Context
+}
+
+// detach returns a context carrying the values of the parent, such as the
+// verbosity, that isn't done when the parent is
+func detach(parent context.Context) context.Context {
+	return detachedCtx{parent}
+}
+
+func (detachedCtx) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedCtx) Done() <-chan struct{}       { return nil }
+func (detachedCtx) Err() error                  { return nil }
@@ -0,0 +1,66 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestExtend(t *testing.T) {
+	c := New(exec.Command("sleep", "5"))
+	if c.Extend(time.Second) {
+		t.Fatal("expected no deadline to extend")
+	}
+	c.Start()
+	start := time.Now()
+	c.SetDeadline(start.Add(time.Millisecond * 200))
+	time.AfterFunc(time.Millisecond*100, func() { c.Extend(time.Millisecond * 300) })
+	c.Wait(context.Background())
+	if !c.stopped() {
+		t.Fatal("expected the process to be stopped")
+	}
+	if elapsed := time.Since(start); elapsed < time.Millisecond*450 || elapsed > time.Second*2 {
+		t.Fatalf("expected to stop after the extended deadline, stopped after %v", elapsed)
+	}
+	if c.Extend(time.Second) {
+		t.Fatal("expected no extension after the deadline passed")
+	}
+}
+
+func TestMaxRuntime(t *testing.T) {
+	c := New(exec.Command("sleep", "5"))
+	c.MaxRuntime = time.Millisecond * 200
+	start := time.Now()
+	c.Run(context.Background())
+	if time.Since(start) > time.Second*2 {
+		t.Fatal("expected the command to be stopped after MaxRuntime")
+	}
+	if !c.stopped() {
+		t.Fatal("expected stop")
+	}
+}
+
+func TestSetDeadline_Same(t *testing.T) {
+	var w watchdog
+	var wg sync.WaitGroup
+	d := time.Now()
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				w.set(d)
+			}
+		}()
+	}
+	wg.Wait()
+	select {
+	case <-w.expiredChan():
+	case <-time.After(time.Second):
+		t.Fatal("expected the watchdog to expire")
+	}
+	time.Sleep(time.Millisecond * 50)
+}
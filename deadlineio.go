@@ -0,0 +1,156 @@
+package ctxexec
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// readDeadliner is implemented by pipes, files and network connections
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// writeDeadliner is implemented by pipes, files and network connections
+type writeDeadliner interface {
+	SetWriteDeadline(t time.Time) error
+}
+
+// deadlineIO sets per-operation deadlines derived from a context
+type deadlineIO struct {
+	ctx     context.Context
+	timeout time.Duration
+	mu      sync.Mutex
+	set     func(time.Time) error
+	stop    chan struct{} // stop is closed to stop following the context
+	once    sync.Once
+}
+
+func newDeadlineIO(ctx context.Context, timeout time.Duration, set func(time.Time) error) *deadlineIO {
+	d := &deadlineIO{ctx: ctx, timeout: timeout, set: set, stop: make(chan struct{})}
+	if set != nil && ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+			case <-d.stop:
+				return
+			}
+			d.mu.Lock()
+			d.set(time.Now())
+			d.mu.Unlock()
+		}()
+	}
+	return d
+}
+
+// close stops following the context and closes c, if not nil
+func (d *deadlineIO) close(c io.Closer) error {
+	d.once.Do(func() { close(d.stop) })
+	if c == nil {
+		return nil
+	}
+	return c.Close()
+}
+
+// begin sets the deadline of the next operation, the earliest of the
+// context's deadline and the timeout from now
+func (d *deadlineIO) begin() error {
+	if err := d.ctx.Err(); err != nil {
+		return err
+	}
+	if d.set == nil {
+		return nil
+	}
+	var deadline time.Time
+	if d.timeout > 0 {
+		deadline = time.Now().Add(d.timeout)
+	}
+	if t, ok := d.ctx.Deadline(); ok && (deadline.IsZero() || t.Before(deadline)) {
+		deadline = t
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.ctx.Err(); err != nil {
+		return err
+	}
+	return d.set(deadline)
+}
+
+// end returns the context's error in place of the operation's error
+// when the context is done
+func (d *deadlineIO) end(err error) error {
+	if err != nil && err != io.EOF {
+		if cerr := d.ctx.Err(); cerr != nil {
+			return cerr
+		}
+	}
+	return err
+}
+
+type deadlineReader struct {
+	r io.Reader
+	d *deadlineIO
+}
+
+// NewDeadlineReader returns a reader whose every Read must complete within
+// timeout, and before the context's deadline. Reads return the context's
+// error once it is done, or an error matching os.ErrDeadlineExceeded
+// when they time out.
+//
+// Deadlines are only enforced on readers that have a SetReadDeadline method,
+// such as pipes and network connections; other readers are only checked for
+// the context being done before each Read.
+//
+// Close closes r if it is an io.Closer. Until it is closed or the context
+// is done, the reader holds a goroutine interrupting Read once it is.
+func NewDeadlineReader(ctx context.Context, r io.Reader, timeout time.Duration) io.ReadCloser {
+	var set func(time.Time) error
+	if rd, ok := r.(readDeadliner); ok {
+		set = rd.SetReadDeadline
+	}
+	return &deadlineReader{r: r, d: newDeadlineIO(ctx, timeout, set)}
+}
+
+func (r *deadlineReader) Read(p []byte) (int, error) {
+	if err := r.d.begin(); err != nil {
+		return 0, err
+	}
+	n, err := r.r.Read(p)
+	return n, r.d.end(err)
+}
+
+func (r *deadlineReader) Close() error {
+	c, _ := r.r.(io.Closer)
+	return r.d.close(c)
+}
+
+type deadlineWriter struct {
+	w io.Writer
+	d *deadlineIO
+}
+
+// NewDeadlineWriter returns a writer whose every Write must complete within
+// timeout, and before the context's deadline, see NewDeadlineReader. Close
+// closes w if it is an io.Closer.
+func NewDeadlineWriter(ctx context.Context, w io.Writer, timeout time.Duration) io.WriteCloser {
+	var set func(time.Time) error
+	if wd, ok := w.(writeDeadliner); ok {
+		set = wd.SetWriteDeadline
+	}
+	return &deadlineWriter{w: w, d: newDeadlineIO(ctx, timeout, set)}
+}
+
+func (w *deadlineWriter) Write(p []byte) (int, error) {
+	if err := w.d.begin(); err != nil {
+		return 0, err
+	}
+	n, err := w.w.Write(p)
+	return n, w.d.end(err)
+}
+
+func (w *deadlineWriter) Close() error {
+	c, _ := w.w.(io.Closer)
+	return w.d.close(c)
+}
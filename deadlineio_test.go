@@ -0,0 +1,78 @@
+package ctxexec
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestDeadlineReader(t *testing.T) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+	defer pw.Close()
+
+	r := NewDeadlineReader(context.Background(), pr, time.Millisecond*100)
+	pw.Write([]byte("x"))
+	if n, err := r.Read(make([]byte, 1)); n != 1 || err != nil {
+		t.Fatalf("expected a byte, got %d %v", n, err)
+	}
+	if _, err := r.Read(make([]byte, 1)); !os.IsTimeout(err) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r = NewDeadlineReader(ctx, pr, time.Minute)
+	time.AfterFunc(time.Millisecond*100, cancel)
+	start := time.Now()
+	if _, err := r.Read(make([]byte, 1)); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("expected read to return promptly")
+	}
+}
+
+func TestDeadlineWriter(t *testing.T) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+	defer pw.Close()
+
+	w := NewDeadlineWriter(context.Background(), pw, time.Millisecond*100)
+	big := make([]byte, 1<<20) // larger than the pipe buffer
+	if _, err := w.Write(big); !os.IsTimeout(err) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+}
+
+func TestDeadlineReader_Close(t *testing.T) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pw.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	before := runtime.NumGoroutine()
+	r := NewDeadlineReader(ctx, pr, 0)
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; runtime.NumGoroutine() > before; i++ {
+		if i == 100 {
+			t.Fatal("expected the goroutine following the context to exit on Close")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	if _, err := pr.Read(make([]byte, 1)); err == nil {
+		t.Fatal("expected the pipe to be closed")
+	}
+}
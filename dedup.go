@@ -0,0 +1,87 @@
+package ctxexec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// DedupWriter collapses runs of identical lines written to it into the line
+// followed by "last message repeated N times", protecting log storage from
+// commands repeating the same warning.
+//
+// Lines are forwarded as they complete, Close flushes the pending repeat
+// count and partial line. It is safe for concurrent use, so the same writer
+// can be used for Stdout and Stderr.
+type DedupWriter struct {
+	w       io.Writer
+	mu      sync.Mutex
+	buf     []byte // buf holds the partial line
+	last    []byte // last is the last line forwarded
+	repeats int
+}
+
+// NewDedupWriter returns a DedupWriter forwarding to w
+func NewDedupWriter(w io.Writer) *DedupWriter {
+	return &DedupWriter{w: w}
+}
+
+// Write implements io.Writer
+func (d *DedupWriter) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.buf = append(d.buf, p...)
+	for {
+		i := bytes.IndexByte(d.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := d.buf[:i+1]
+		if err := d.line(line); err != nil {
+			return len(p), err
+		}
+		d.buf = d.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// line forwards a complete line unless it repeats the last one
+func (d *DedupWriter) line(line []byte) error {
+	if d.last != nil && bytes.Equal(line, d.last) {
+		d.repeats++
+		return nil
+	}
+	if err := d.flushRepeats(); err != nil {
+		return err
+	}
+	d.last = append(d.last[:0], line...)
+	_, err := d.w.Write(line)
+	return err
+}
+
+func (d *DedupWriter) flushRepeats() error {
+	if d.repeats == 0 {
+		return nil
+	}
+	n := d.repeats
+	d.repeats = 0
+	_, err := fmt.Fprintf(d.w, "last message repeated %d times\n", n)
+	return err
+}
+
+// Close writes the pending repeat count and partial line, it doesn't
+// close the underlying writer
+func (d *DedupWriter) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.flushRepeats(); err != nil {
+		return err
+	}
+	if len(d.buf) > 0 {
+		_, err := d.w.Write(d.buf)
+		d.buf = nil
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package ctxexec
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestDedupWriter(t *testing.T) {
+	var out bytes.Buffer
+	d := NewDedupWriter(&out)
+	cmd := exec.Command("bash", "-c", `for i in 1 2 3 4; do echo warn; done; echo done; printf tail`)
+	cmd.Stdout = d
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+	defer cancel()
+	Run(ctx, cmd)
+	d.Close()
+	if want := "warn\nlast message repeated 3 times\ndone\ntail"; out.String() != want {
+		t.Fatalf("expected %q, got %q", want, out.String())
+	}
+}
@@ -0,0 +1,79 @@
+package ctxexec
+
+import (
+	"log"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Options are the termination and logging settings applied to the commands
+// created with New, unless overridden on the command
+type Options struct {
+	// Grace is the time given to the command to exit gracefully before
+	// it is killed
+	Grace time.Duration
+
+	// StopSignals are sent in order to stop the command gracefully
+	StopSignals []os.Signal
+
+	// ProcessGroup starts the command in a new process group, so the stop
+	// signals and the kill reach its descendants
+	ProcessGroup bool
+
+	// Logger is the logger of the command, the package Logger when nil
+	Logger *log.Logger
+}
+
+// packageDefaults are the built-in defaults
+var packageDefaults = Options{
+	Grace:       10 * time.Second,
+	StopSignals: []os.Signal{os.Interrupt, syscall.SIGTERM},
+}
+
+var (
+	defaultsMu sync.RWMutex
+	defaults   = packageDefaults
+)
+
+// SetDefaults sets the options applied to the commands created with New
+// afterwards, so a codebase can enforce a consistent termination policy
+// from one place. Zero fields keep the package defaults.
+func SetDefaults(o Options) {
+	if o.Grace == 0 {
+		o.Grace = packageDefaults.Grace
+	}
+	if len(o.StopSignals) == 0 {
+		o.StopSignals = packageDefaults.StopSignals
+	}
+	defaultsMu.Lock()
+	defaults = o
+	defaultsMu.Unlock()
+}
+
+// Defaults returns the options applied to the commands created with New
+func Defaults() Options {
+	defaultsMu.RLock()
+	defer defaultsMu.RUnlock()
+	return defaults
+}
+
+// grace returns the command's Grace, the default one when not set
+func (c *CtxCmd) grace() time.Duration {
+	if c.Grace > 0 {
+		return c.Grace
+	}
+	return Defaults().Grace
+}
+
+// stopSignals returns the signals that stop the command carried by the
+// context, the default ones when not set
+func stopSignals(ctx context.Context) []os.Signal {
+	if c, ok := ctx.Value(cmdKey{}).(*CtxCmd); ok && len(c.StopSignals) > 0 {
+		return c.StopSignals
+	}
+	return Defaults().StopSignals
+}
@@ -0,0 +1,36 @@
+package ctxexec
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSetDefaults(t *testing.T) {
+	defer SetDefaults(Options{})
+	SetDefaults(Options{Grace: time.Second, StopSignals: []os.Signal{syscall.SIGHUP}})
+	c := New(exec.Command("bash", "-c", `trap "exit 0" SIGHUP; while true; do sleep 0.1; done`))
+	if c.Grace != time.Second {
+		t.Fatalf("expected the default grace, got %v", c.Grace)
+	}
+	c.Start()
+	time.Sleep(time.Millisecond * 200)
+	if err := c.Close(); err != nil {
+		t.Fatalf("expected the command to exit on SIGHUP, got %v", err)
+	}
+
+	SetDefaults(Options{})
+	if d := Defaults(); d.Grace != packageDefaults.Grace || len(d.StopSignals) != 2 {
+		t.Fatalf("expected the package defaults, got %+v", d)
+	}
+}
+
+func TestSetDefaults_ProcessGroup(t *testing.T) {
+	defer SetDefaults(Options{})
+	SetDefaults(Options{ProcessGroup: true})
+	if c := New(exec.Command("true")); !c.ProcessGroup {
+		t.Fatal("expected the default process group")
+	}
+}
@@ -0,0 +1,31 @@
+package ctxexec
+
+// Done returns a channel closed once the command started, its process
+// exited and its resources were released, so completion can be selected on
+// alongside other events. It is never closed if the command fails to start.
+//
+// The process is reaped in the background, use Wait or Err rather than
+// Cmd.Wait once Done was called.
+func (c *CtxCmd) Done() <-chan struct{} {
+	c.doneOnce.Do(func() {
+		c.done = make(chan struct{})
+		go func() {
+			<-c.startedChan()
+			c.labelGoroutine()
+			<-c.reap()
+			close(c.done)
+		}()
+	})
+	return c.done
+}
+
+// Err returns nil while Done isn't closed. Once it is, Err returns the
+// error of the process, as Wait would have if the context isn't done.
+func (c *CtxCmd) Err() error {
+	select {
+	case <-c.Done():
+		return c.exitError()
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,29 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestDone(t *testing.T) {
+	c := New(exec.Command("bash", "-c", "sleep 0.2; exit 2"))
+	done := c.Done()
+	if c.Err() != nil {
+		t.Fatal("expected no error before the start")
+	}
+	c.Start()
+	select {
+	case <-done:
+		t.Fatal("expected Done to be open while running")
+	case <-time.After(time.Millisecond * 50):
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second * 2):
+		t.Fatal("expected Done to be closed once the process exited")
+	}
+	if code, _ := c.ExitCode(); c.Err() == nil || code != 2 {
+		t.Fatalf("expected the exit error, got %v", c.Err())
+	}
+}
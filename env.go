@@ -0,0 +1,112 @@
+package ctxexec
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// DeadlineEnv is the environment variable holding the run deadline in RFC 3339 format
+	DeadlineEnv = "CTXEXEC_DEADLINE"
+	// TimeoutEnv is the environment variable holding the milliseconds left until the deadline
+	TimeoutEnv = "CTXEXEC_TIMEOUT_MS"
+)
+
+// exportDeadline adds DeadlineEnv and TimeoutEnv to the command's
+// environment when the context or the command has a deadline
+func (c *CtxCmd) exportDeadline(ctx context.Context) {
+	deadline, ok := ctx.Deadline()
+	if d, wok := c.Deadline(); wok && (!ok || d.Before(deadline)) {
+		deadline, ok = d, true
+	}
+	if !ok {
+		return
+	}
+	if c.Cmd.Env == nil {
+		c.Cmd.Env = os.Environ()
+	}
+	timeout := deadline.Sub(time.Now()) / time.Millisecond
+	if timeout < 0 {
+		timeout = 0
+	}
+	c.Cmd.Env = append(c.Cmd.Env,
+		DeadlineEnv+"="+deadline.Format(time.RFC3339Nano),
+		TimeoutEnv+"="+strconv.FormatInt(int64(timeout), 10),
+	)
+}
+
+// EnvSet sets the environment variable of the command, replacing any
+// previous value. The environment is inherited from the process when
+// the command has none.
+func (c *CtxCmd) EnvSet(key, value string) {
+	envSet(c.Cmd, key, value)
+}
+
+// EnvUnset removes the environment variable of the command, the
+// environment is inherited from the process when the command has none
+func (c *CtxCmd) EnvUnset(key string) {
+	envUnset(c.Cmd, key)
+}
+
+// EnvAppendPath appends dirs to a list of paths in the environment of the
+// command, such as PATH, joined by the platform's separator. The
+// environment is inherited from the process when the command has none.
+func (c *CtxCmd) EnvAppendPath(key string, dirs ...string) {
+	list := envGet(c.Cmd, key)
+	for _, dir := range dirs {
+		if list != "" {
+			list += string(os.PathListSeparator)
+		}
+		list += dir
+	}
+	envSet(c.Cmd, key, list)
+}
+
+func envSet(cmd *exec.Cmd, key, value string) {
+	envUnset(cmd, key)
+	cmd.Env = append(cmd.Env, key+"="+value)
+}
+
+func envUnset(cmd *exec.Cmd, key string) {
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	// the command's Env may share its array with other commands
+	env := make([]string, 0, len(cmd.Env)+1)
+	for _, kv := range cmd.Env {
+		if k := strings.SplitN(kv, "=", 2)[0]; !envKeyEqual(k, key) {
+			env = append(env, kv)
+		}
+	}
+	cmd.Env = env
+}
+
+// envGet returns the value of the environment variable of the command,
+// the last one wins as in exec
+func envGet(cmd *exec.Cmd, key string) string {
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	value := ""
+	for _, kv := range env {
+		if kv := strings.SplitN(kv, "=", 2); len(kv) == 2 && envKeyEqual(kv[0], key) {
+			value = kv[1]
+		}
+	}
+	return value
+}
+
+// envKeyEqual compares environment variable names, case-insensitively on Windows
+func envKeyEqual(a, b string) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
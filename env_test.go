@@ -0,0 +1,63 @@
+package ctxexec
+
+import (
+	"bytes"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestExportDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+	var out bytes.Buffer
+	cmd := exec.Command("bash", "-c", `echo "$CTXEXEC_TIMEOUT_MS $CTXEXEC_DEADLINE"`)
+	cmd.Stdout = &out
+	c := New(cmd)
+	c.ExportDeadline = true
+	if err := c.StartContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	c.Cmd.Wait()
+
+	fields := strings.Fields(out.String())
+	if len(fields) != 2 {
+		t.Fatalf("expected timeout and deadline, got %q", out.String())
+	}
+	if ms, err := strconv.Atoi(fields[0]); err != nil || ms <= 0 || ms > 2000 {
+		t.Fatalf("unexpected timeout %q", fields[0])
+	}
+	if _, err := time.Parse(time.RFC3339Nano, fields[1]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnvHelpers(t *testing.T) {
+	c := New(exec.Command("true"))
+	c.Env = []string{"PATH=/bin", "HOME=/root", "LANG=C", "HOME=/tmp"}
+	c.EnvSet("LANG", "en_US.UTF-8")
+	c.EnvUnset("HOME")
+	c.EnvAppendPath("PATH", "/opt/bin", "/usr/local/bin")
+	c.EnvAppendPath("GOPATH", "/go")
+	want := "LANG=en_US.UTF-8 PATH=/bin:/opt/bin:/usr/local/bin GOPATH=/go"
+	if got := strings.Join(c.Env, " "); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestEnvUnset_Shared(t *testing.T) {
+	base := []string{"A=1", "B=2", "C=3"}
+	c := New(exec.Command("env"))
+	c.Env = base
+	c.EnvUnset("A")
+	if base[0] != "A=1" || base[1] != "B=2" || base[2] != "C=3" {
+		t.Fatalf("expected the shared environment untouched, got %q", base)
+	}
+	if strings.Join(c.Env, " ") != "B=2 C=3" {
+		t.Fatalf("expected A unset, got %q", c.Env)
+	}
+}
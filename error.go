@@ -0,0 +1,68 @@
+package ctxexec
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+)
+
+// ErrorTailBytes is the size of the tail of the standard error kept in an
+// Error
+var ErrorTailBytes = 4 << 10
+
+// Error is the error returned by Wait for commands with RichErrors that
+// exit with a failure. It wraps the *exec.ExitError.
+type Error struct {
+	Command  string // Command is the command line, formatted with String
+	ExitCode int    // ExitCode is the exit code, -1 if terminated by a signal
+	Stderr   []byte // Stderr is the last ErrorTailBytes of the standard error
+	Err      error  // Err is the *exec.ExitError
+}
+
+// Error returns the command line, the exit status and the last line of the
+// standard error
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("ctxexec: %s: %v", e.Command, e.Err)
+	if tail := bytes.TrimSpace(e.Stderr); len(tail) > 0 {
+		if i := bytes.LastIndexByte(tail, '\n'); i >= 0 {
+			tail = tail[i+1:]
+		}
+		msg += ": " + string(tail)
+	}
+	return msg
+}
+
+// Unwrap returns the *exec.ExitError, for errors.As and errors.Is
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// captureStderr tees the command's Stderr to a ring buffer keeping the
+// last ErrorTailBytes, unless it is already captured
+func (c *CtxCmd) captureStderr() {
+	c.mu.Lock()
+	captured := c.tail[1] != nil
+	c.mu.Unlock()
+	if !captured {
+		stderr := NewRingBuffer(ErrorTailBytes, 0)
+		c.teeOutputs(nil, stderr)
+		c.mu.Lock()
+		c.tail[1] = stderr
+		c.mu.Unlock()
+	}
+}
+
+// richError wraps the exit error in an Error
+func (c *CtxCmd) richError(ee *exec.ExitError) *Error {
+	e := &Error{Command: c.String(), ExitCode: ee.ExitCode(), Err: ee}
+	c.mu.Lock()
+	stderr := c.tail[1]
+	c.mu.Unlock()
+	if stderr != nil {
+		e.Stderr = stderr.Bytes()
+		if len(e.Stderr) > ErrorTailBytes {
+			e.Stderr = e.Stderr[len(e.Stderr)-ErrorTailBytes:]
+		}
+	}
+	return e
+}
@@ -0,0 +1,33 @@
+package ctxexec
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestRichErrors(t *testing.T) {
+	c := New(exec.Command("bash", "-c", `echo "$(head -c 8000 /dev/zero | tr '\0' x)" >&2; echo "no such table" >&2; exit 4`), WithRichErrors())
+	err := c.Run(context.Background())
+	e, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expected an *Error, got %#v", err)
+	}
+	if e.ExitCode != 4 || len(e.Stderr) != ErrorTailBytes || !strings.HasSuffix(string(e.Stderr), "no such table\n") {
+		t.Fatalf("unexpected error %d, %d bytes of stderr", e.ExitCode, len(e.Stderr))
+	}
+	if msg := e.Error(); !strings.HasPrefix(msg, "ctxexec: bash -c") || !strings.HasSuffix(msg, "exit status 4: no such table") {
+		t.Fatalf("unexpected message %q", msg)
+	}
+	var ee *exec.ExitError
+	if !errors.As(err, &ee) || ee.ExitCode() != 4 {
+		t.Fatalf("expected the exit error to be wrapped, got %v", err)
+	}
+	// successful commands and success exit codes aren't errors
+	if err := New(exec.Command("bash", "-c", "exit 1"), WithRichErrors(), WithSuccessExitCodes(1)).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,79 @@
+package ctxexec
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// EscalationStep sends Signal then waits up to Wait for the process to exit
+// before the next step
+type EscalationStep struct {
+	Signal os.Signal
+	Wait   time.Duration
+}
+
+// EscalationPolicy is a signal ladder, such as SIGINT, wait 2s, SIGTERM,
+// wait 5s, SIGKILL, giving daemons time to shut down between the signals
+type EscalationPolicy []EscalationStep
+
+// Escalate returns a StopFunc climbing the ladder of the policy until the
+// process exits. It is killed once the ladder is exhausted, the waits of
+// the ladder bound the stop rather than the context, which is often
+// already done, such as when Wait stops the command.
+func Escalate(p EscalationPolicy) StopFunc {
+	return func(ctx context.Context, cmd *exec.Cmd) error {
+		if cmd == nil || cmd.Process == nil {
+			return nil
+		}
+		exited, waitErr := exited(ctx, cmd)
+		for _, step := range p {
+			signal(ctx, cmd, step.Signal)
+			if step.Wait <= 0 {
+				continue
+			}
+			t := time.NewTimer(step.Wait)
+			select {
+			case <-exited:
+				t.Stop()
+				return waitErr()
+			case <-t.C:
+				logf(ctx, Debug, "pid %d: still running after %v", cmd.Process.Pid, step.Wait)
+			}
+		}
+		select {
+		case <-exited:
+			return waitErr()
+		default:
+		}
+		kill(ctx, cmd)
+		<-exited
+		return waitErr()
+	}
+}
+
+// escalateStrategy parses comma separated signal names, each followed by
+// an optional duration to wait before the next one
+func escalateStrategy(arg string) (StopFunc, error) {
+	var p EscalationPolicy
+	for _, f := range strings.Split(arg, ",") {
+		f = strings.TrimSpace(f)
+		if d, err := time.ParseDuration(f); err == nil {
+			if len(p) == 0 {
+				return nil, fmt.Errorf("ctxexec: no signal before %q", f)
+			}
+			p[len(p)-1].Wait = d
+			continue
+		}
+		sig, ok := parseSignal(f)
+		if !ok {
+			return nil, fmt.Errorf("ctxexec: unknown signal %q", f)
+		}
+		p = append(p, EscalationStep{Signal: sig})
+	}
+	return Escalate(p), nil
+}
@@ -0,0 +1,95 @@
+package ctxexec
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestEscalate(t *testing.T) {
+	c := New(exec.Command("bash", "-c", `trap "" SIGINT; trap "exit 0" SIGTERM; while true; do sleep 0.1; done`),
+		WithEscalation(EscalationPolicy{
+			{Signal: syscall.SIGINT, Wait: time.Millisecond * 300},
+			{Signal: syscall.SIGTERM, Wait: time.Second * 2},
+			{Signal: syscall.SIGKILL},
+		}))
+	c.Start()
+	time.Sleep(time.Millisecond * 100) // let bash install the traps
+	if err := c.Stop(context.Background()); err != nil {
+		t.Fatalf("expected the command to exit on SIGTERM, got %v", err)
+	}
+	var sent []os.Signal
+	var times []time.Time
+	for _, e := range c.Timeline() {
+		switch e.Kind {
+		case EventKill:
+			t.Fatal("expected no kill")
+		case EventSignal:
+			sent = append(sent, e.Signal)
+			times = append(times, e.Time)
+		}
+	}
+	if len(sent) != 2 || sent[0] != syscall.SIGINT || sent[1] != syscall.SIGTERM {
+		t.Fatalf("expected SIGINT then SIGTERM, got %v", sent)
+	}
+	if d := times[1].Sub(times[0]); d < time.Millisecond*300 {
+		t.Fatalf("expected a pause between the signals, got %v", d)
+	}
+}
+
+func TestEscalate_Exhausted(t *testing.T) {
+	stop, err := LookupStopStrategy("escalate:INT,100ms,TERM,100ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New(exec.Command("bash", "-c", `trap "" SIGINT SIGTERM; sleep 5`), WithStopFunc(stop))
+	c.Start()
+	time.Sleep(time.Millisecond * 100) // let bash install the traps
+	start := time.Now()
+	c.Stop(context.Background())
+	if time.Since(start) > time.Second*2 {
+		t.Fatal("expected a kill once the ladder is exhausted")
+	}
+	killed := false
+	for _, e := range c.Timeline() {
+		killed = killed || e.Kind == EventKill
+	}
+	if !killed {
+		t.Fatalf("expected a kill, got %v", c.Timeline())
+	}
+	if _, err := LookupStopStrategy("escalate:1s,TERM"); err == nil {
+		t.Fatal("expected a duration before any signal to be rejected")
+	}
+}
+
+func TestEscalate_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := New(exec.Command("bash", "-c", `trap "" SIGINT; trap "exit 0" SIGTERM; while true; do sleep 0.1; done`),
+		WithEscalation(EscalationPolicy{
+			{Signal: syscall.SIGINT, Wait: time.Millisecond * 300},
+			{Signal: syscall.SIGTERM, Wait: time.Second * 2},
+		}), WithGrace(time.Millisecond*100))
+	c.Start()
+	time.Sleep(time.Millisecond * 100) // let bash install the traps
+	cancel()
+	c.Wait(ctx)
+	var times []time.Time
+	for _, e := range c.Timeline() {
+		switch e.Kind {
+		case EventKill:
+			t.Fatal("expected no kill")
+		case EventSignal:
+			times = append(times, e.Time)
+		}
+	}
+	if len(times) != 2 || times[1].Sub(times[0]) < time.Millisecond*300 {
+		t.Fatalf("expected the ladder to wait despite the cancelled context, got %v", c.Timeline())
+	}
+	if !c.ProcessState.Success() {
+		t.Fatalf("expected the command to exit on SIGTERM, got %v", c.ProcessState)
+	}
+}
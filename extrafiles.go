@@ -0,0 +1,68 @@
+package ctxexec
+
+import (
+	"errors"
+	"os"
+	"strconv"
+
+	"golang.org/x/net/context"
+)
+
+// PipeFrom attaches the write end of a new pipe to the command through
+// ExtraFiles and returns the read end. The child finds the file descriptor
+// number in the environment variable env.
+//
+// The child's end is closed in the parent once the command started, the
+// returned end is closed when the context is done. It must be called before
+// the command is started.
+func (c *CtxCmd) PipeFrom(ctx context.Context, env string) (*os.File, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.attach(ctx, env, w, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// PipeTo is like PipeFrom for a pipe the command reads from, it returns
+// the write end
+func (c *CtxCmd) PipeTo(ctx context.Context, env string) (*os.File, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.attach(ctx, env, r, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// attach passes child to the command through ExtraFiles and closes parent
+// when the context is done
+func (c *CtxCmd) attach(ctx context.Context, env string, child, parent *os.File) error {
+	if c.Cmd.Process != nil {
+		child.Close()
+		parent.Close()
+		return errors.New("ctxexec: file attached after the process started")
+	}
+	fd := 3 + len(c.Cmd.ExtraFiles)
+	c.Cmd.ExtraFiles = append(c.Cmd.ExtraFiles, child)
+	if c.Cmd.Env == nil {
+		c.Cmd.Env = os.Environ()
+	}
+	c.Cmd.Env = append(c.Cmd.Env, env+"="+strconv.Itoa(fd))
+	c.closeAfterStart = append(c.closeAfterStart, child)
+	exited := make(chan struct{})
+	c.onExit = append(c.onExit, func() { close(exited) })
+	c.onStartFailure = append(c.onStartFailure, func() { close(exited) })
+	go func() {
+		select {
+		case <-ctx.Done():
+			parent.Close()
+		case <-exited:
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,24 @@
+package ctxexec
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestPipeFrom(t *testing.T) {
+	c := New(exec.Command("bash", "-c", `echo status >&$STATUS_FD`))
+	r, err := c.PipeFrom(context.Background(), "STATUS_FD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	c.Start()
+	out, err := ioutil.ReadAll(r)
+	if err != nil || string(out) != "status\n" {
+		t.Fatalf("unexpected output %q, %v", out, err)
+	}
+	WaitAll(context.Background(), c)
+}
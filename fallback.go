@@ -0,0 +1,65 @@
+package ctxexec
+
+import (
+	"os/exec"
+
+	"golang.org/x/net/context"
+)
+
+// Fallback runs alternative commands in order, such as xdg-open, open and
+// start, until one succeeds
+type Fallback struct {
+	// ExitCodes are the exit codes for which Run moves on to the next
+	// alternative, any non-zero exit code when empty
+	ExitCodes []int
+}
+
+// RunFirstSuccess runs the alternative commands with a Fallback moving on
+// after any non-zero exit code, see Fallback.Run
+func RunFirstSuccess(ctx context.Context, cmds ...*CtxCmd) (int, error) {
+	return Fallback{}.Run(ctx, cmds...)
+}
+
+// Run runs the alternative commands in order until one succeeds and
+// returns its index. It moves on when a command's binary isn't found or it
+// exits with one of the ExitCodes, other errors are returned right away.
+//
+// Each command runs to completion, stopped as by Wait if the context is
+// done first. When no alternative succeeds, the index is -1 and the error
+// is the last one.
+func (f Fallback) Run(ctx context.Context, cmds ...*CtxCmd) (int, error) {
+	var err error
+	for i, c := range cmds {
+		if err = c.StartContext(ctx); err == nil {
+			err = waitExit(ctx, c)
+		}
+		if err == nil {
+			return i, nil
+		}
+		if ctx.Err() != nil || !f.fallback(err) {
+			return i, err
+		}
+		logf(withCmd(ctx, c), Debug, "%q: %v, trying the next alternative", c.Args, err)
+	}
+	return -1, err
+}
+
+// fallback returns true if Run moves on after the error
+func (f Fallback) fallback(err error) bool {
+	switch err := err.(type) {
+	case *exec.Error:
+		return err.Err == exec.ErrNotFound
+	case *Error:
+		return f.fallback(err.Err)
+	case *exec.ExitError:
+		if len(f.ExitCodes) == 0 {
+			return true
+		}
+		for _, code := range f.ExitCodes {
+			if err.ExitCode() == code {
+				return true
+			}
+		}
+	}
+	return false
+}
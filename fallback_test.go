@@ -0,0 +1,30 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestRunFirstSuccess(t *testing.T) {
+	ctx := context.Background()
+	i, err := RunFirstSuccess(ctx,
+		New(exec.Command("ctxexec-missing")),
+		New(exec.Command("bash", "-c", "exit 3")),
+		New(exec.Command("true")),
+		New(exec.Command("false")),
+	)
+	if i != 2 || err != nil {
+		t.Fatalf("expected the third alternative to succeed, got %d, %v", i, err)
+	}
+
+	i, err = Fallback{ExitCodes: []int{127}}.Run(ctx,
+		New(exec.Command("bash", "-c", "exit 127")),
+		New(exec.Command("bash", "-c", "exit 3")),
+		New(exec.Command("true")),
+	)
+	if ee, ok := err.(*exec.ExitError); i != 1 || !ok || ee.ExitCode() != 3 {
+		t.Fatalf("expected the second alternative to fail, got %d, %v", i, err)
+	}
+}
@@ -0,0 +1,87 @@
+//go:build !windows
+// +build !windows
+
+package ctxexec
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"golang.org/x/net/context"
+)
+
+// FIFO is a named pipe in its own temporary directory, for commands that
+// only accept file arguments while the data is produced on the fly
+type FIFO struct {
+	Path string // Path is the path of the named pipe, to pass in argv
+}
+
+// NewFIFO creates a named pipe called name in a new temporary directory
+func NewFIFO(name string) (*FIFO, error) {
+	dir, err := ioutil.TempDir("", "ctxexec")
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, name)
+	if err := syscall.Mkfifo(path, 0600); err != nil {
+		os.RemoveAll(dir)
+		return nil, &os.PathError{Op: "mkfifo", Path: path, Err: err}
+	}
+	return &FIFO{Path: path}, nil
+}
+
+// FIFO creates a named pipe that is removed once the process is reaped,
+// see NewFIFO
+func (c *CtxCmd) FIFO(name string) (*FIFO, error) {
+	f, err := NewFIFO(name)
+	if err != nil {
+		return nil, err
+	}
+	c.Cleanup(func(context.Context) { f.Remove() })
+	return f, nil
+}
+
+// OpenRead opens the named pipe for reading, waiting for a writer until
+// the context is done
+func (f *FIFO) OpenRead(ctx context.Context) (*os.File, error) {
+	return f.open(ctx, os.O_RDONLY, os.O_WRONLY)
+}
+
+// OpenWrite opens the named pipe for writing, waiting for a reader until
+// the context is done
+func (f *FIFO) OpenWrite(ctx context.Context) (*os.File, error) {
+	return f.open(ctx, os.O_WRONLY, os.O_RDONLY)
+}
+
+// open opens the named pipe with flag in the background, opening the other
+// end with unblock when the context is done so the background open returns
+func (f *FIFO) open(ctx context.Context, flag, unblock int) (*os.File, error) {
+	type result struct {
+		file *os.File
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		file, err := os.OpenFile(f.Path, flag, 0)
+		done <- result{file, err}
+	}()
+	select {
+	case r := <-done:
+		return r.file, r.err
+	case <-ctx.Done():
+	}
+	if other, err := os.OpenFile(f.Path, unblock|syscall.O_NONBLOCK, 0); err == nil {
+		defer other.Close()
+	}
+	if r := <-done; r.file != nil {
+		r.file.Close()
+	}
+	return nil, ctx.Err()
+}
+
+// Remove removes the named pipe and its temporary directory
+func (f *FIFO) Remove() error {
+	return os.RemoveAll(filepath.Dir(f.Path))
+}
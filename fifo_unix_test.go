@@ -0,0 +1,56 @@
+//go:build !windows
+// +build !windows
+
+package ctxexec
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestFIFO(t *testing.T) {
+	cmd := exec.Command("bash", "-c", `echo data > "$0"`)
+	c := New(cmd)
+	f, err := c.FIFO("out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd.Args = append(cmd.Args, f.Path)
+	c.Start()
+	r, err := f.OpenRead(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := ioutil.ReadAll(r)
+	r.Close()
+	if string(out) != "data\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+	WaitAll(context.Background(), c)
+	if _, err := os.Stat(f.Path); !os.IsNotExist(err) {
+		t.Fatalf("expected the FIFO to be removed, got %v", err)
+	}
+}
+
+func TestFIFO_OpenCanceled(t *testing.T) {
+	f, err := NewFIFO("in")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Remove()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	if _, err := f.OpenWrite(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	if _, err := f.OpenRead(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+//go:build !windows
+// +build !windows
+
+package ctxexec
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// FileLock is a Lock using flock(2) on files in a directory, shared by the
+// processes of a host, or of several hosts on a file system supporting it
+type FileLock struct {
+	Dir    string // Dir is where the lock files are created, as <name>.lock
+	NoWait bool   // NoWait fails with ErrLocked instead of waiting for the lock
+}
+
+// Lock implements Lock, waiting for the lock by checking every PollInterval
+func (l FileLock) Lock(ctx context.Context, name string) (func(), error) {
+	f, err := os.OpenFile(filepath.Join(l.Dir, name+".lock"), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if err != syscall.EWOULDBLOCK {
+			f.Close()
+			return nil, err
+		}
+		if l.NoWait {
+			f.Close()
+			return nil, ErrLocked
+		}
+		select {
+		case <-time.After(PollInterval):
+		case <-ctx.Done():
+			f.Close()
+			return nil, ctx.Err()
+		}
+	}
+	var once sync.Once
+	return func() { once.Do(func() { f.Close() }) }, nil
+}
@@ -0,0 +1,47 @@
+//go:build !windows
+// +build !windows
+
+package ctxexec
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestFileLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ctxexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	first := New(exec.Command("sleep", "0.3"))
+	first.Lock = FileLock{Dir: dir}
+	if err := first.Start(); err != nil {
+		t.Fatal(err)
+	}
+	second := New(exec.Command("sleep", "0"))
+	second.Lock = FileLock{Dir: dir, NoWait: true}
+	second.LockName = "sleep"
+	if err := second.Start(); err != ErrLocked {
+		t.Fatalf("expected %v, got %v", ErrLocked, err)
+	}
+	third := New(exec.Command("true"))
+	third.Lock = FileLock{Dir: dir}
+	third.LockName = "sleep"
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+	if err := third.StartContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-first.reap():
+	case <-time.After(time.Millisecond * 100):
+		t.Fatal("expected the third command to wait for the first to exit")
+	}
+	<-third.reap()
+}
@@ -0,0 +1,88 @@
+package ctxexec
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// SecretPattern matches the names of the flags and environment variables
+// whose values String redacts
+var SecretPattern = regexp.MustCompile(`(?i)(passw(or)?d|secret|token|api[-_]?key|credential|auth)`)
+
+// MaxStringArgs is the number of arguments String shows before eliding
+// the rest
+var MaxStringArgs = 16
+
+// String renders the command for logs, errors and dry runs as a shell
+// command line. Environment variables set for the command, compared to
+// the current environment, are shown as KEY=… before it, the values of
+// flags matching SecretPattern are redacted and arguments past
+// MaxStringArgs are elided.
+func (c *CtxCmd) String() string {
+	var parts []string
+	for _, key := range envOverrides(c.Env) {
+		parts = append(parts, key+"=…")
+	}
+	args := c.Args
+	if len(args) == 0 {
+		args = []string{c.Path}
+	}
+	redactNext := false
+	for i, arg := range args {
+		if MaxStringArgs > 0 && i > MaxStringArgs {
+			parts = append(parts, fmt.Sprintf("… (%d more)", len(args)-i))
+			break
+		}
+		if redactNext {
+			parts = append(parts, "<redacted>")
+			redactNext = false
+			continue
+		}
+		if i > 0 && strings.HasPrefix(arg, "-") {
+			if j := strings.IndexByte(arg, '='); j > 0 {
+				if SecretPattern.MatchString(arg[:j]) {
+					parts = append(parts, shellQuote(arg[:j+1])+"<redacted>")
+					continue
+				}
+			} else {
+				redactNext = SecretPattern.MatchString(arg)
+			}
+		}
+		parts = append(parts, shellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// envOverrides returns the keys of the variables of env that are not set
+// to the same value in the current environment
+func envOverrides(env []string) []string {
+	if env == nil {
+		return nil
+	}
+	current := map[string]bool{}
+	for _, kv := range os.Environ() {
+		current[kv] = true
+	}
+	var keys []string
+	for _, kv := range env {
+		if current[kv] {
+			continue
+		}
+		if i := strings.IndexByte(kv, '='); i > 0 {
+			keys = append(keys, kv[:i])
+		}
+	}
+	return keys
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes s for a POSIX shell when needed
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
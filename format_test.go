@@ -0,0 +1,26 @@
+package ctxexec
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	cmd := exec.Command("pg_dump", "-Fc", "--password", "hunter2", "--api-key=abc", "it's", "")
+	cmd.Env = append(os.Environ(), "PGPASSWORD=hunter2")
+	got := New(cmd).String()
+	want := `PGPASSWORD=… pg_dump -Fc --password <redacted> --api-key=<redacted> 'it'\''s' ''`
+	if got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+
+	cmd = exec.Command("echo")
+	for i := 0; i < 20; i++ {
+		cmd.Args = append(cmd.Args, strconv.Itoa(i))
+	}
+	if got, want := New(cmd).String(), "echo 0 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 … (4 more)"; got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,34 @@
+package ctxexec
+
+import (
+	"os"
+	ossignal "os/signal"
+
+	"golang.org/x/net/context"
+)
+
+// forwardSignals relays the ForwardSignals received by the program to the
+// process until it exits
+func (c *CtxCmd) forwardSignals(ctx context.Context) {
+	ctx = withCmd(ctx, c)
+	ch := make(chan os.Signal, len(c.ForwardSignals))
+	done := make(chan struct{})
+	ossignal.Notify(ch, c.ForwardSignals...)
+	c.onExit = append(c.onExit, func() {
+		ossignal.Stop(ch)
+		close(done)
+	})
+	go func() {
+		c.labelGoroutine()
+		for {
+			select {
+			case sig := <-ch:
+				if err := c.Signal(sig); err != nil {
+					logf(ctx, Errors, "pid %d: forward %v: %v", pidOf(c.Cmd), sig, err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,27 @@
+package ctxexec
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestForwardSignals(t *testing.T) {
+	var out bytes.Buffer
+	c := New(exec.Command("bash", "-c", `trap "echo hup; exit 0" SIGHUP; while true; do sleep 0.1; done`), WithForwardSignals(syscall.SIGHUP))
+	c.Stdout = &out
+	c.Start()
+	time.Sleep(time.Millisecond * 100) // let bash install the trap
+	p, _ := os.FindProcess(os.Getpid())
+	p.Signal(syscall.SIGHUP)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+	if err := c.Wait(ctx); err != nil || out.String() != "hup\n" {
+		t.Fatalf("expected the signal to be forwarded, got %q, %v", out.String(), err)
+	}
+}
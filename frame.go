@@ -0,0 +1,78 @@
+package ctxexec
+
+import (
+	"errors"
+
+	"golang.org/x/net/context"
+)
+
+// Stream identifies an output stream of a command
+type Stream int
+
+const (
+	// StreamStdout is the standard output
+	StreamStdout Stream = 1
+	// StreamStderr is the standard error
+	StreamStderr Stream = 2
+)
+
+// String returns the name of the stream
+func (s Stream) String() string {
+	switch s {
+	case StreamStdout:
+		return "stdout"
+	case StreamStderr:
+		return "stderr"
+	}
+	return "unknown"
+}
+
+// Frame is a chunk of raw output of a command
+type Frame struct {
+	Stream Stream // Stream is the stream the data was written to
+	Offset int64  // Offset is the offset of the data in the stream
+	Data   []byte // Data is the raw output, owned by the receiver
+}
+
+// Frames returns a channel delivering the raw output of the command as
+// frames, for binary output that can't be split in lines. It must be called
+// before the command is started and the channel is closed once the process
+// is reaped, or once it failed to start.
+//
+// Frames are sent as they are written, so a slow receiver slows down the
+// command. Output written once the context is done is discarded.
+//
+// The command is reaped in the background, use Wait rather than Cmd.Wait on it.
+func (c *CtxCmd) Frames(ctx context.Context) (<-chan Frame, error) {
+	if c.Cmd.Process != nil {
+		return nil, errors.New("ctxexec: frames requested after the process started")
+	}
+	if c.Cmd.Stdout != nil || c.Cmd.Stderr != nil {
+		return nil, errors.New("ctxexec: Stdout or Stderr already set")
+	}
+	frames := make(chan Frame)
+	c.Cmd.Stdout = &frameWriter{ctx: ctx, stream: StreamStdout, frames: frames}
+	c.Cmd.Stderr = &frameWriter{ctx: ctx, stream: StreamStderr, frames: frames}
+	c.onExit = append(c.onExit, func() { close(frames) })
+	c.onStartFailure = append(c.onStartFailure, func() { close(frames) })
+	return frames, nil
+}
+
+// frameWriter sends the data written to it as frames
+type frameWriter struct {
+	ctx    context.Context
+	stream Stream
+	offset int64
+	frames chan<- Frame
+}
+
+func (w *frameWriter) Write(p []byte) (int, error) {
+	f := Frame{Stream: w.stream, Offset: w.offset, Data: append([]byte(nil), p...)}
+	select {
+	case w.frames <- f:
+		w.offset += int64(len(p))
+		return len(p), nil
+	case <-w.ctx.Done():
+		return 0, w.ctx.Err()
+	}
+}
@@ -0,0 +1,50 @@
+package ctxexec
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestFrames(t *testing.T) {
+	c := New(exec.Command("bash", "-c", `printf '\x00\x01\x02'; printf 'err' >&2`))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+	frames, err := c.Frames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Start()
+	out := map[Stream][]byte{}
+	for f := range frames {
+		if f.Offset != int64(len(out[f.Stream])) {
+			t.Fatalf("unexpected offset %d for %v", f.Offset, f.Stream)
+		}
+		out[f.Stream] = append(out[f.Stream], f.Data...)
+	}
+	if !bytes.Equal(out[StreamStdout], []byte{0, 1, 2}) || string(out[StreamStderr]) != "err" {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
+
+func TestFrames_StartFailure(t *testing.T) {
+	c := New(exec.Command("/nonexistent/binary"))
+	frames, err := c.Frames(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Start() == nil {
+		t.Fatal("expected the start to fail")
+	}
+	select {
+	case _, ok := <-frames:
+		if ok {
+			t.Fatal("expected no frame")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the frames to be closed once the start failed")
+	}
+}
@@ -0,0 +1,54 @@
+package ctxexec
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+)
+
+// StartGang starts all the commands or none of them. It waits until slots
+// for every command are available in the pool, reserving them at once, so
+// no member runs while the others are still queued. Each slot is released
+// once its process exited.
+//
+// If a command fails to start, or the pool is drained meanwhile, the ones
+// already started are killed and the error is returned. The errors returned while waiting are the ones
+// of Acquire.
+func (p *Pool) StartGang(ctx context.Context, key string, cmds ...*CtxCmd) error {
+	if p.Size > 0 && len(cmds) > p.Size {
+		return fmt.Errorf("ctxexec: gang of %d commands exceeds the pool size of %d", len(cmds), p.Size)
+	}
+	if err := p.acquire(ctx, key, len(cmds)); err != nil {
+		return err
+	}
+	releases := make([]func(), len(cmds))
+	for i := range cmds {
+		releases[i] = p.releaser()
+	}
+	tracked := make([]*poolCmd, len(cmds))
+	for i, c := range cmds {
+		tracked[i] = p.track(c)
+		c.onExit = append(c.onExit, releases[i], tracked[i].finish)
+	}
+	for i, c := range cmds {
+		n := i // n is the number of commands started
+		err := c.StartContext(ctx)
+		if err == nil {
+			n++
+			if !p.markStarted(tracked[i]) {
+				err = ErrDraining
+			}
+		}
+		if err != nil {
+			for j := n; j < len(cmds); j++ {
+				releases[j]()
+				tracked[j].finish()
+			}
+			for _, started := range cmds[:n] {
+				kill(withCmd(ctx, started), started.Cmd)
+			}
+			return err
+		}
+	}
+	return nil
+}
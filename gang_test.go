@@ -0,0 +1,104 @@
+package ctxexec
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestPool_StartGang(t *testing.T) {
+	p := &Pool{Size: 2}
+	ctx := context.Background()
+	hold, _ := p.Acquire(ctx, "")
+	a, b := New(exec.Command("true")), New(exec.Command("true"))
+	started := make(chan error)
+	go func() {
+		started <- p.StartGang(ctx, "gang", a, b)
+	}()
+	time.Sleep(time.Millisecond * 20)
+	// a single slot is free, later submissions queue behind the gang
+	single, cancel := context.WithTimeout(ctx, time.Millisecond*20)
+	defer cancel()
+	if _, err := p.Acquire(single, ""); err == nil {
+		t.Fatal("expected the gang to hold back later submissions")
+	}
+	select {
+	case <-started:
+		t.Fatal("expected the gang to wait for both slots")
+	default:
+	}
+	hold()
+	if err := <-started; err != nil {
+		t.Fatal(err)
+	}
+	WaitAll(ctx, a, b)
+	if err := p.StartGang(ctx, "gang", a, b, New(exec.Command("true"))); err == nil {
+		t.Fatal("expected an error for a gang larger than the pool")
+	}
+}
+
+func TestPool_StartGang_Failure(t *testing.T) {
+	p := &Pool{Size: 2}
+	ctx := context.Background()
+	a, b := New(exec.Command("sleep", "5")), New(exec.Command("/nonexistent"))
+	if err := p.StartGang(ctx, "", a, b); err == nil {
+		t.Fatal("expected the gang to fail to start")
+	}
+	<-a.reap()
+	release, err := p.Acquire(ctx, "")
+	if err != nil || p.running != 1 {
+		t.Fatalf("expected the slots to be released, got %d running, %v", p.running, err)
+	}
+	release()
+}
+
+func TestPool_StartGang_ProcessGroup(t *testing.T) {
+	a, grandchild := groupCommand(t)
+	b := New(exec.Command("/nonexistent"))
+	b.StartWhen = []Condition{func(context.Context) error { grandchild(); return nil }}
+	if err := (&Pool{Size: 2}).StartGang(context.Background(), "", a, b); err == nil {
+		t.Fatal("expected the gang to fail to start")
+	}
+	<-a.reap()
+	if !exitedPid(grandchild()) {
+		t.Fatal("expected the process group to be killed")
+	}
+}
+
+// groupCommand returns a command starting a grandchild in its process
+// group, and a function returning the pid of the grandchild once started
+func groupCommand(t *testing.T) (*CtxCmd, func() string) {
+	dir, err := ioutil.TempDir("", "ctxexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	pidFile := filepath.Join(dir, "pid")
+	c := New(exec.Command("sh", "-c", `sleep 5 & echo $! > `+pidFile+`; wait`), WithProcessGroup())
+	return c, func() string {
+		var pid []byte
+		for i := 0; i < 100 && len(pid) == 0; i++ {
+			pid, _ = ioutil.ReadFile(pidFile)
+			time.Sleep(time.Millisecond * 10)
+		}
+		return strings.TrimSpace(string(pid))
+	}
+}
+
+// exitedPid returns true once the process exited, within a second
+func exitedPid(pid string) bool {
+	for i := 0; i < 100; i++ {
+		out, _ := exec.Command("ps", "-o", "stat=", "-p", pid).Output()
+		if len(out) == 0 || strings.HasPrefix(string(out), "Z") {
+			return true
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package ctxexec
+
+import (
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// Group runs commands concurrently, at most Limit at a time
+type Group struct {
+	// Limit is the maximum number of commands running at once, zero means
+	// no limit
+	Limit int
+
+	// FailFast stops the running commands, and doesn't start the pending
+	// ones, once a command fails
+	FailFast bool
+
+	// Output, when set, interleaves the output of the commands, prefixed by
+	// their "name" label or the base name of their path and their index
+	Output *Mux
+
+	newCmds []func() *CtxCmd
+}
+
+// Go adds the command returned by newCmd to the group, it is created and
+// run by Wait
+func (g *Group) Go(newCmd func() *CtxCmd) {
+	g.newCmds = append(g.newCmds, newCmd)
+}
+
+// Wait runs the commands of the group with RunResult and returns their
+// Results, in the order they were added, along with the first error. The
+// Result of a command that wasn't run because of FailFast is nil.
+func (g *Group) Wait(ctx context.Context) ([]*Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	limit := g.Limit
+	if limit <= 0 {
+		limit = len(g.newCmds)
+	}
+	results := make([]*Result, len(g.newCmds))
+	sem := make(chan struct{}, limit)
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		first error
+	)
+	for i, newCmd := range g.newCmds {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int, newCmd func() *CtxCmd) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			c := newCmd()
+			if g.Output != nil {
+				g.Output.Attach(muxName(c, i), c)
+			}
+			r, err := c.RunResult(ctx)
+			results[i] = r
+			if err != nil {
+				once.Do(func() { first = err })
+				if g.FailFast {
+					cancel()
+				}
+			}
+		}(i, newCmd)
+	}
+	wg.Wait()
+	if first == nil {
+		first = ctx.Err()
+	}
+	return results, first
+}
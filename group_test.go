@@ -0,0 +1,49 @@
+package ctxexec
+
+import (
+	"fmt"
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestGroup(t *testing.T) {
+	g := &Group{Limit: 2}
+	for i := 0; i < 4; i++ {
+		run := fmt.Sprintf("sleep 0.2; echo %d", i)
+		g.Go(func() *CtxCmd { return New(exec.Command("bash", "-c", run)) })
+	}
+	start := time.Now()
+	results, err := g.Wait(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < time.Millisecond*400 {
+		t.Fatalf("expected at most 2 commands at once, took %v", d)
+	}
+	for i, r := range results {
+		if string(r.Stdout) != fmt.Sprintf("%d\n", i) {
+			t.Fatalf("expected the results in order, got %q for %d", r.Stdout, i)
+		}
+	}
+}
+
+func TestGroup_FailFast(t *testing.T) {
+	g := &Group{Limit: 2, FailFast: true}
+	g.Go(func() *CtxCmd { return New(exec.Command("sleep", "5")) })
+	g.Go(func() *CtxCmd { return New(exec.Command("bash", "-c", "sleep 0.1; exit 1")) })
+	g.Go(func() *CtxCmd { return New(exec.Command("sleep", "5")) })
+	start := time.Now()
+	results, err := g.Wait(context.Background())
+	if err == nil || results[1].ExitCode != 1 {
+		t.Fatalf("expected the failure, got %v", err)
+	}
+	if !results[0].Stopped || results[2] != nil {
+		t.Fatalf("expected the running command stopped and the pending one skipped, got %+v, %+v", results[0], results[2])
+	}
+	if time.Since(start) > time.Second*2 {
+		t.Fatal("expected the group to fail fast")
+	}
+}
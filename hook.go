@@ -0,0 +1,42 @@
+package ctxexec
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// DefaultHookTimeout bounds the Before and After commands that have
+// no MaxRuntime
+var DefaultHookTimeout = time.Minute
+
+// runHook runs a Before or After command to completion
+func runHook(ctx context.Context, h *CtxCmd) error {
+	if err := h.StartContext(ctx); err != nil {
+		return err
+	}
+	if _, ok := h.Deadline(); !ok {
+		h.SetDeadline(time.Now().Add(DefaultHookTimeout))
+	}
+	return waitExit(ctx, h)
+}
+
+// runBefore runs the Before commands in order, stopping at the first failure
+func (c *CtxCmd) runBefore(ctx context.Context) error {
+	for _, h := range c.Before {
+		logf(ctx, Debug, "before %q: run %q", c.Args, h.Args)
+		if err := runHook(ctx, h); err != nil {
+			logf(ctx, Errors, "before %q: %q: %v", c.Args, h.Args, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// runAfter runs all the After commands in order, with a context that isn't
+// done when the run context is so they still run after a cancellation
+func (c *CtxCmd) runAfter() {
+	for _, h := range c.After {
+		runHook(context.Background(), h)
+	}
+}
@@ -0,0 +1,88 @@
+package ctxexec
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestBeforeAfter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ctxexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, "tmp")
+
+	c := New(exec.Command("bash", "-c", `test -d "$0" && sleep 5`, tmp))
+	c.Before = []*CtxCmd{New(exec.Command("mkdir", tmp))}
+	c.After = []*CtxCmd{New(exec.Command("rm", "-rf", tmp))}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*300)
+	defer cancel()
+	c.Run(ctx)
+	if !c.stopped() {
+		t.Fatal("expected the command to be stopped")
+	}
+	if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+		t.Fatalf("expected the After command to run, got %v", err)
+	}
+}
+
+func TestBefore_Failure(t *testing.T) {
+	c := New(exec.Command("true"))
+	c.Before = []*CtxCmd{New(exec.Command("false"))}
+	if err := c.Start(); err == nil {
+		t.Fatal("expected the Before failure to be returned")
+	}
+	if c.Cmd.Process != nil {
+		t.Fatal("expected the command not to start")
+	}
+}
+
+func TestAfter_StartFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ctxexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, "tmp")
+
+	marker := filepath.Join(dir, "marker")
+
+	c := New(exec.Command(filepath.Join(dir, "nonexistent")))
+	c.Before = []*CtxCmd{New(exec.Command("mkdir", tmp)), New(exec.Command("touch", marker))}
+	c.After = []*CtxCmd{New(exec.Command("rm", "-rf", tmp))}
+	if err := c.Start(); err == nil {
+		t.Fatal("expected the start to fail")
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Fatalf("expected the Before commands to run, got %v", err)
+	}
+	if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+		t.Fatalf("expected the After command to run, got %v", err)
+	}
+}
+
+func TestBefore_Rejected(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ctxexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, "tmp")
+
+	c := New(exec.Command("true"))
+	c.Policy = &Rules{Deny: []string{"true"}}
+	c.Before = []*CtxCmd{New(exec.Command("mkdir", tmp))}
+	if _, ok := c.Start().(*PolicyError); !ok {
+		t.Fatal("expected the command to be rejected")
+	}
+	if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+		t.Fatalf("expected the Before command not to run, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package ctxexec
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+var installHints = struct {
+	sync.Mutex
+	m map[string]map[string]string
+}{m: map[string]map[string]string{}}
+
+// RegisterInstallHints registers install suggestions for the named binary
+// used by EnsureInstalled, keyed by runtime.GOOS, such as "darwin": "brew
+// install ffmpeg", with an optional "" key for the other platforms
+func RegisterInstallHints(name string, hints map[string]string) {
+	installHints.Lock()
+	defer installHints.Unlock()
+	installHints.m[name] = hints
+}
+
+// NotInstalledError is returned by EnsureInstalled when a binary isn't
+// found in the PATH
+type NotInstalledError struct {
+	Name string // Name is the binary's name
+	Hint string // Hint is the install suggestion for the platform, if any
+	Err  error  // Err is the error of exec.LookPath
+}
+
+func (e *NotInstalledError) Error() string {
+	if e.Hint == "" {
+		return fmt.Sprintf("ctxexec: %s not found in PATH", e.Name)
+	}
+	return fmt.Sprintf("ctxexec: %s not found in PATH, install it with: %s", e.Name, e.Hint)
+}
+
+// Unwrap returns the error of exec.LookPath, for errors.As and errors.Is
+func (e *NotInstalledError) Unwrap() error {
+	return e.Err
+}
+
+// EnsureInstalled returns the path of the named binary, or a
+// *NotInstalledError suggesting how to install it on the platform. The
+// hints, keyed as in RegisterInstallHints, take precedence over the
+// registered ones.
+func EnsureInstalled(ctx context.Context, name string, hints map[string]string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	path, err := exec.LookPath(name)
+	if err == nil {
+		return path, nil
+	}
+	installHints.Lock()
+	registered := installHints.m[name]
+	installHints.Unlock()
+	hint := ""
+	for _, h := range []map[string]string{hints, registered} {
+		if hint = h[runtime.GOOS]; hint != "" {
+			break
+		}
+		if hint = h[""]; hint != "" {
+			break
+		}
+	}
+	return "", &NotInstalledError{Name: name, Hint: hint, Err: err}
+}
@@ -0,0 +1,29 @@
+package ctxexec
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestEnsureInstalled(t *testing.T) {
+	ctx := context.Background()
+	if path, err := EnsureInstalled(ctx, "bash", nil); err != nil || path == "" {
+		t.Fatalf("expected the path of bash, got %q, %v", path, err)
+	}
+	RegisterInstallHints("ctxexec-missing", map[string]string{"": "get it somewhere"})
+	_, err := EnsureInstalled(ctx, "ctxexec-missing", nil)
+	if nerr, ok := err.(*NotInstalledError); !ok || nerr.Hint != "get it somewhere" {
+		t.Fatalf("expected a *NotInstalledError with the registered hint, got %v", err)
+	}
+	if !errors.Is(err, exec.ErrNotFound) {
+		t.Fatalf("expected the error to wrap %v, got %v", exec.ErrNotFound, err)
+	}
+	_, err = EnsureInstalled(ctx, "ctxexec-missing", map[string]string{runtime.GOOS: "install it"})
+	if want := "ctxexec: ctxexec-missing not found in PATH, install it with: install it"; err == nil || err.Error() != want {
+		t.Fatalf("expected %q, got %v", want, err)
+	}
+}
@@ -0,0 +1,141 @@
+package ctxexec
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Interceptor rewrites a command just before it is executed, for instance
+// to run it under nice or add proxy environment variables. It returns an
+// error to prevent the command from running.
+type Interceptor func(cmd *exec.Cmd) error
+
+var (
+	interceptorsMu sync.RWMutex
+	interceptors   []Interceptor
+)
+
+// AddInterceptor registers an Interceptor applied to every command, before
+// the command's own Interceptors
+func AddInterceptor(i Interceptor) {
+	interceptorsMu.Lock()
+	interceptors = append(interceptors, i)
+	interceptorsMu.Unlock()
+}
+
+// intercept applies the registered interceptors then the command's own
+func (c *CtxCmd) intercept() error {
+	interceptorsMu.RLock()
+	all := append([]Interceptor(nil), interceptors...)
+	interceptorsMu.RUnlock()
+	for _, i := range append(all, c.Interceptors...) {
+		if err := i(c.Cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Prefix returns an Interceptor that runs the command under another one,
+// such as "nice", "-n", "19". The command is passed by its resolved path.
+func Prefix(name string, args ...string) Interceptor {
+	return func(cmd *exec.Cmd) error {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			return err
+		}
+		argv := append([]string{name}, args...)
+		argv = append(argv, cmd.Path)
+		if len(cmd.Args) > 1 {
+			argv = append(argv, cmd.Args[1:]...)
+		}
+		cmd.Path, cmd.Args = path, argv
+		return nil
+	}
+}
+
+// SetEnv returns an Interceptor that adds the KEY=value pairs to the
+// command's environment, inherited from the process when not set
+func SetEnv(kv ...string) Interceptor {
+	return func(cmd *exec.Cmd) error {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, kv...)
+		return nil
+	}
+}
+
+// PathInterceptor returns an Interceptor that resolves the command within
+// dirs only, such as a vendored toolchain, independently of the PATH. The
+// resolved absolute path is recorded as the command's Path. Commands named
+// by a path are left as is.
+func PathInterceptor(dirs ...string) Interceptor {
+	return func(cmd *exec.Cmd) error {
+		name := cmd.Path
+		if len(cmd.Args) > 0 {
+			name = cmd.Args[0]
+		}
+		if strings.ContainsRune(name, filepath.Separator) || strings.ContainsRune(name, '/') {
+			return nil
+		}
+		for _, dir := range dirs {
+			path, err := exec.LookPath(filepath.Join(dir, name))
+			if err != nil {
+				continue
+			}
+			if path, err = filepath.Abs(path); err != nil {
+				return err
+			}
+			cmd.Path, cmd.Err = path, nil
+			return nil
+		}
+		return &exec.Error{Name: name, Err: exec.ErrNotFound}
+	}
+}
+
+// RestrictedPathInterceptor returns an Interceptor that resolves the
+// command within dirs as PathInterceptor does, and sets the PATH of the
+// command to dirs so the programs it runs are also resolved within them
+func RestrictedPathInterceptor(dirs ...string) Interceptor {
+	resolve := PathInterceptor(dirs...)
+	setPath := SetEnv("PATH=" + strings.Join(dirs, string(os.PathListSeparator)))
+	return func(cmd *exec.Cmd) error {
+		if err := resolve(cmd); err != nil {
+			return err
+		}
+		return setPath(cmd)
+	}
+}
+
+// ForceColor returns an Interceptor setting the conventional environment
+// variables, FORCE_COLOR, CLICOLOR_FORCE and TERM when unset or dumb, that
+// make programs color their output even though it isn't a terminal
+func ForceColor() Interceptor {
+	return func(cmd *exec.Cmd) error {
+		envUnset(cmd, "NO_COLOR")
+		envSet(cmd, "FORCE_COLOR", "1")
+		envSet(cmd, "CLICOLOR_FORCE", "1")
+		if term := envGet(cmd, "TERM"); term == "" || term == "dumb" {
+			envSet(cmd, "TERM", "xterm-256color")
+		}
+		return nil
+	}
+}
+
+// NoColor returns an Interceptor setting the conventional environment
+// variables, NO_COLOR, CLICOLOR and TERM, that make programs print plain
+// output even when the parent has a terminal
+func NoColor() Interceptor {
+	return func(cmd *exec.Cmd) error {
+		envUnset(cmd, "FORCE_COLOR")
+		envUnset(cmd, "CLICOLOR_FORCE")
+		envSet(cmd, "NO_COLOR", "1")
+		envSet(cmd, "CLICOLOR", "0")
+		envSet(cmd, "TERM", "dumb")
+		return nil
+	}
+}
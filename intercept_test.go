@@ -0,0 +1,69 @@
+package ctxexec
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInterceptors(t *testing.T) {
+	var out bytes.Buffer
+	cmd := exec.Command("bash", "-c", `echo "$(nice) $HTTP_PROXY"`)
+	cmd.Stdout = &out
+	c := New(cmd)
+	c.Interceptors = []Interceptor{Prefix("nice", "-n", "5"), SetEnv("HTTP_PROXY=proxy:3128")}
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	c.Cmd.Wait()
+	if got := strings.TrimSpace(out.String()); got != "5 proxy:3128" {
+		t.Fatalf("expected the command to be rewritten, got %q", got)
+	}
+}
+
+func TestRestrictedPathInterceptor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ctxexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tool := filepath.Join(dir, "ctxexec-tool")
+	if err := ioutil.WriteFile(tool, []byte("#!/bin/sh\necho \"$PATH\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	cmd := exec.Command("ctxexec-tool")
+	cmd.Stdout = &out
+	c := New(cmd)
+	c.Interceptors = []Interceptor{RestrictedPathInterceptor(dir)}
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	c.Cmd.Wait()
+	if c.Path != tool || strings.TrimSpace(out.String()) != dir {
+		t.Fatalf("expected %s run with PATH %s, got %s with %q", tool, dir, c.Path, out.String())
+	}
+
+	c = New(exec.Command("bash", "-c", "true"))
+	c.Interceptors = []Interceptor{PathInterceptor(dir)}
+	if err := c.Start(); err == nil {
+		t.Fatal("expected bash not to be found")
+	}
+}
+
+func TestColor(t *testing.T) {
+	cmd := exec.Command("true")
+	cmd.Env = []string{"NO_COLOR=1", "TERM=dumb"}
+	ForceColor()(cmd)
+	if got, want := strings.Join(cmd.Env, " "), "FORCE_COLOR=1 CLICOLOR_FORCE=1 TERM=xterm-256color"; got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+	NoColor()(cmd)
+	if got, want := strings.Join(cmd.Env, " "), "NO_COLOR=1 CLICOLOR=0 TERM=dumb"; got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,79 @@
+package ctxexec
+
+import (
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// global limits the concurrent processes started by the package
+var global limiter
+
+// SetMaxConcurrent caps the number of child processes running at once
+// across the package, commands wait for a slot before starting. A value
+// of zero or less removes the cap.
+//
+// Lowering the cap doesn't stop running processes, new ones wait until
+// enough of them exited. Processes started while there was no cap don't
+// count against it.
+func SetMaxConcurrent(n int) {
+	global.setMax(n)
+}
+
+// limiter is a counting semaphore whose acquisition honors a context
+type limiter struct {
+	mu      sync.Mutex
+	max     int
+	running int
+	wake    chan struct{} // wake is closed when a slot may have become available
+}
+
+func (l *limiter) setMax(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.max = n
+	l.broadcast()
+}
+
+// acquire waits for a slot, it returns the function releasing it, nil
+// when there is no cap, or the context's error if it is done first
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	for {
+		l.mu.Lock()
+		if l.max <= 0 {
+			l.mu.Unlock()
+			return nil, nil
+		}
+		if l.running < l.max {
+			l.running++
+			l.mu.Unlock()
+			var once sync.Once
+			return func() { once.Do(l.release) }, nil
+		}
+		if l.wake == nil {
+			l.wake = make(chan struct{})
+		}
+		wake := l.wake
+		l.mu.Unlock()
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (l *limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.running--
+	l.broadcast()
+}
+
+// broadcast wakes the waiters, l.mu must be held
+func (l *limiter) broadcast() {
+	if l.wake != nil {
+		close(l.wake)
+		l.wake = nil
+	}
+}
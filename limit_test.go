@@ -0,0 +1,31 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestSetMaxConcurrent(t *testing.T) {
+	SetMaxConcurrent(1)
+	defer SetMaxConcurrent(0)
+	first := New(exec.Command("sleep", "0.2"))
+	if err := first.Start(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	if err := New(exec.Command("true")).StartContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected %v while the slot is taken, got %v", context.DeadlineExceeded, err)
+	}
+	second := New(exec.Command("true"))
+	if err := second.StartContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if first.ProcessState == nil {
+		t.Fatal("expected the second command to start after the first exited")
+	}
+	WaitAll(context.Background(), second)
+}
@@ -0,0 +1,62 @@
+package ctxexec
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// lineWriter calls f with each line written to it, without the line ending
+type lineWriter struct {
+	mu  sync.Mutex
+	f   func(line string)
+	buf []byte // buf holds the partial line
+}
+
+// Write implements io.Writer
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.f(string(bytes.TrimSuffix(w.buf[:i], []byte{'\r'})))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush calls f with the pending partial line, if any
+func (w *lineWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.f(string(w.buf))
+		w.buf = nil
+	}
+}
+
+// attachLineFuncs tees the command's Stdout and Stderr to the StdoutLines
+// and StderrLines callbacks, flushing the last partial lines once the
+// process exited
+func (c *CtxCmd) attachLineFuncs() {
+	var stdout, stderr io.Writer
+	var writers []*lineWriter
+	if c.StdoutLines != nil {
+		w := &lineWriter{f: c.StdoutLines}
+		stdout, writers = w, append(writers, w)
+	}
+	if c.StderrLines != nil {
+		w := &lineWriter{f: c.StderrLines}
+		stderr, writers = w, append(writers, w)
+	}
+	c.teeOutputs(stdout, stderr)
+	c.onExit = append(c.onExit, func() {
+		for _, w := range writers {
+			w.flush()
+		}
+	})
+}
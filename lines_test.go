@@ -0,0 +1,65 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestLineFuncs(t *testing.T) {
+	var mu sync.Mutex
+	var stdout, stderr []string
+	c := New(exec.Command("bash", "-c", `echo one; printf 'two\r\n'; echo err >&2; printf partial`),
+		WithStdoutLines(func(line string) { mu.Lock(); stdout = append(stdout, line); mu.Unlock() }),
+		WithStderrLines(func(line string) { mu.Lock(); stderr = append(stderr, line); mu.Unlock() }),
+	)
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(stdout, []string{"one", "two", "partial"}) || !reflect.DeepEqual(stderr, []string{"err"}) {
+		t.Fatalf("unexpected lines %q, %q", stdout, stderr)
+	}
+}
+
+func TestLineFuncs_Stop(t *testing.T) {
+	lines := make(chan string, 10)
+	c := New(exec.Command("bash", "-c", `echo started; exec sleep 5`), WithStdoutLines(func(line string) { lines <- line }))
+	ctx, cancel := context.WithCancel(context.Background())
+	c.Start()
+	if line := <-lines; line != "started" {
+		t.Fatalf("expected the line while running, got %q", line)
+	}
+	cancel()
+	done := make(chan struct{})
+	go func() { c.Wait(ctx); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(time.Second * 2):
+		t.Fatal("expected Wait to return once stopped")
+	}
+}
+
+func TestLineFuncs_Grandchild(t *testing.T) {
+	var mu sync.Mutex
+	var stdout []string
+	c := New(exec.Command("sh", "-c", "sleep 5 & echo hi"),
+		WithStdoutLines(func(line string) { mu.Lock(); stdout = append(stdout, line); mu.Unlock() }))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	c.Run(ctx)
+	if d := time.Since(start); d > time.Second*3 {
+		t.Fatalf("expected Wait not to hang on the pipe held by the grandchild, took %v", d)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(stdout, []string{"hi"}) {
+		t.Fatalf("unexpected lines %q", stdout)
+	}
+}
@@ -0,0 +1,56 @@
+package ctxexec
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// ListeningPorts reports the TCP addresses, as host:port, the running
+// command and its descendants start listening on, such as the port
+// chosen by a server started on port 0. Each address is reported once.
+//
+// Sockets are checked every PollInterval, the channel is closed once the
+// process exits or the context is done. It is only supported on Linux.
+func (c *CtxCmd) ListeningPorts(ctx context.Context) (<-chan string, error) {
+	if c.Process == nil {
+		return nil, errors.New("ctxexec: watching the ports of a command that isn't started")
+	}
+	pid := c.Process.Pid
+	if _, err := listeningAddrs(pid); err != nil {
+		return nil, err
+	}
+	addrs := make(chan string)
+	go func() {
+		defer close(addrs)
+		exited := c.reap()
+		seen := map[string]bool{}
+		ticker := time.NewTicker(PollInterval)
+		defer ticker.Stop()
+		for {
+			found, _ := listeningAddrs(pid)
+			for _, addr := range found {
+				if seen[addr] {
+					continue
+				}
+				seen[addr] = true
+				select {
+				case addrs <- addr:
+				case <-exited:
+					return
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-exited:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return addrs, nil
+}
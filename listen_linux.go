@@ -0,0 +1,97 @@
+package ctxexec
+
+import (
+	"bufio"
+	"encoding/hex"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listeningAddrs returns the addresses the process and its descendants
+// listen on, matching the inodes of their sockets with the listening
+// sockets of the process's network namespace
+func listeningAddrs(pid int) ([]string, error) {
+	procs, err := processTable()
+	if err != nil {
+		return nil, err
+	}
+	inodes := map[string]bool{}
+	for _, pid := range append([]int{pid}, descendants(procs, pid)...) {
+		dir := "/proc/" + strconv.Itoa(pid) + "/fd"
+		fds, err := readDirNames(dir)
+		if err != nil {
+			continue // the process exited
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(dir + "/" + fd)
+			if err == nil && strings.HasPrefix(link, "socket:[") {
+				inodes[link[len("socket:["):len(link)-1]] = true
+			}
+		}
+	}
+	var addrs []string
+	for _, table := range []string{"tcp", "tcp6"} {
+		found, err := listeningSockets("/proc/"+strconv.Itoa(pid)+"/net/"+table, inodes)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		addrs = append(addrs, found...)
+	}
+	return addrs, nil
+}
+
+func readDirNames(dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Readdirnames(-1)
+}
+
+// listeningSockets returns the addresses of the listening sockets of a
+// /proc/net/tcp table whose inode is one of inodes
+func listeningSockets(path string, inodes map[string]bool) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var addrs []string
+	s := bufio.NewScanner(f)
+	s.Scan() // header
+	for s.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(s.Text())
+		if len(fields) < 10 || fields[3] != "0A" || !inodes[fields[9]] {
+			continue
+		}
+		if addr, ok := parseProcAddr(fields[1]); ok {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs, s.Err()
+}
+
+// parseProcAddr parses an address of /proc/net/tcp, the IP in hex as
+// 32-bit words in host order and the port in hex
+func parseProcAddr(s string) (string, bool) {
+	i := strings.IndexByte(s, ':')
+	if i < 0 {
+		return "", false
+	}
+	ip, err := hex.DecodeString(s[:i])
+	if err != nil || (len(ip) != net.IPv4len && len(ip) != net.IPv6len) {
+		return "", false
+	}
+	for w := 0; w < len(ip); w += 4 {
+		ip[w], ip[w+1], ip[w+2], ip[w+3] = ip[w+3], ip[w+2], ip[w+1], ip[w]
+	}
+	port, err := strconv.ParseUint(s[i+1:], 16, 16)
+	if err != nil {
+		return "", false
+	}
+	return net.JoinHostPort(net.IP(ip).String(), strconv.Itoa(int(port))), true
+}
@@ -0,0 +1,52 @@
+package ctxexec
+
+import (
+	"bufio"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestParseProcAddr(t *testing.T) {
+	for s, want := range map[string]string{
+		"0100007F:1F90":                         "127.0.0.1:8080",
+		"00000000000000000000000001000000:0050": "[::1]:80",
+	} {
+		if got, ok := parseProcAddr(s); !ok || got != want {
+			t.Errorf("%s: expected %s, got %s", s, want, got)
+		}
+	}
+}
+
+func TestListeningPorts(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not found")
+	}
+	c := New(exec.Command("bash", "-c", `python3 -c '
+import socket, time
+s = socket.socket()
+s.bind(("127.0.0.1", 0))
+s.listen(1)
+print(s.getsockname()[1], flush=True)
+time.sleep(5)
+'`))
+	out, _ := c.StdoutPipe()
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Process.Kill()
+	line, _ := bufio.NewReader(out).ReadString('\n')
+	port := strings.TrimSpace(line)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+	addrs, err := c.ListeningPorts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr := <-addrs; addr != "127.0.0.1:"+port {
+		t.Fatalf("expected 127.0.0.1:%s, got %q", port, addr)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package ctxexec
+
+import "errors"
+
+// listeningAddrs returns the addresses the process and its descendants listen on
+func listeningAddrs(pid int) ([]string, error) {
+	return nil, errors.New("ctxexec: listening port detection is only supported on Linux")
+}
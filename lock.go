@@ -0,0 +1,38 @@
+package ctxexec
+
+import (
+	"errors"
+	"path/filepath"
+
+	"golang.org/x/net/context"
+)
+
+// ErrLocked is returned by a Lock that doesn't wait when it is held elsewhere
+var ErrLocked = errors.New("ctxexec: lock held elsewhere")
+
+// Lock is a named lock shared across processes or hosts, such as a file
+// lock or a lease in a coordination service, gating the execution of
+// singleton commands
+type Lock interface {
+	// Lock acquires the named lock, waiting until the context is done or
+	// failing fast with ErrLocked, and returns the function releasing it
+	Lock(ctx context.Context, name string) (unlock func(), err error)
+}
+
+// lock acquires the command's Lock, if any
+func (c *CtxCmd) lock(ctx context.Context) (func(), error) {
+	if c.Lock == nil {
+		return nil, nil
+	}
+	name := c.LockName
+	if name == "" {
+		name = filepath.Base(c.Path)
+	}
+	unlock, err := c.Lock.Lock(ctx, name)
+	if err != nil {
+		logf(ctx, Errors, "lock %s: %v", name, err)
+		return nil, err
+	}
+	logf(ctx, Debug, "lock %s: acquired", name)
+	return unlock, nil
+}
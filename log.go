@@ -0,0 +1,128 @@
+package ctxexec
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Verbosity controls how much the package logs for a run
+type Verbosity int
+
+const (
+	// Silent disables logging, it is the default when the context carries no verbosity
+	Silent Verbosity = iota
+	// Errors logs failures to signal, kill or reap the process
+	Errors
+	// Debug logs every lifecycle step, including the full argv
+	Debug
+)
+
+// Logger is the logger the package writes to
+var Logger = log.New(os.Stderr, "ctxexec: ", log.LstdFlags)
+
+type verbosityKey struct{}
+
+// WithVerbosity returns a copy of the parent context that carries the verbosity.
+//
+// Commands run, waited on or stopped with the returned context log according
+// to v, so a single service can mix noisy and quiet commands.
+func WithVerbosity(ctx context.Context, v Verbosity) context.Context {
+	return context.WithValue(ctx, verbosityKey{}, v)
+}
+
+// VerbosityFrom returns the verbosity carried by the context, Silent if none
+func VerbosityFrom(ctx context.Context) Verbosity {
+	if ctx == nil {
+		return Silent
+	}
+	if v, ok := ctx.Value(verbosityKey{}).(Verbosity); ok {
+		return v
+	}
+	return Silent
+}
+
+// logf writes to the logger of the command carried by the context, or
+// Logger, when the context verbosity is at least v. The message is
+// followed by the labels of the command.
+func logf(ctx context.Context, v Verbosity, format string, args ...interface{}) {
+	if VerbosityFrom(ctx) < v {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	logger := Logger
+	if c, ok := ctx.Value(cmdKey{}).(*CtxCmd); ok {
+		if len(c.Labels) > 0 {
+			msg += " " + formatLabels(c.Labels)
+		}
+		if c.Logger != nil {
+			logger = c.Logger
+		}
+	}
+	logger.Print(msg)
+}
+
+// formatLabels formats labels as space separated key=value pairs sorted by key
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
+
+// logAttrs writes a structured record to the Slog logger of the command, if
+// any, with its pid and labels. Unlike logf it doesn't depend on the context
+// verbosity, the records are filtered by the handler of the logger.
+func (c *CtxCmd) logAttrs(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	if c.Slog == nil {
+		return
+	}
+	attrs = append(attrs, slog.Int("pid", pidOf(c.Cmd)))
+	if len(c.Labels) > 0 {
+		labels := make([]any, 0, len(c.Labels))
+		for k, v := range c.Labels {
+			labels = append(labels, slog.String(k, v))
+		}
+		attrs = append(attrs, slog.Group("labels", labels...))
+	}
+	c.Slog.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// logAttrsCtx writes a structured record to the Slog logger of the command
+// carried by the context, if any
+func logAttrsCtx(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	if c, ok := ctx.Value(cmdKey{}).(*CtxCmd); ok {
+		c.logAttrs(ctx, level, msg, attrs...)
+	}
+}
+
+// logExit writes the exit record of the reaped process
+func (c *CtxCmd) logExit() {
+	if c.Slog == nil {
+		return
+	}
+	attrs := []slog.Attr{slog.String("command", c.String())}
+	if started := c.startedAt(); !started.IsZero() {
+		attrs = append(attrs, slog.Duration("duration", time.Since(started)))
+	}
+	level := slog.LevelInfo
+	if c.ProcessState != nil {
+		attrs = append(attrs, slog.Int("exit_code", c.ProcessState.ExitCode()))
+		if sig := exitSignal(c.ProcessState); sig != nil {
+			attrs = append(attrs, slog.String("signal", sig.String()))
+		}
+	}
+	if err := c.exitError(); err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	c.logAttrs(context.Background(), level, "exit", attrs...)
+}
@@ -0,0 +1,95 @@
+package ctxexec
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestVerbosity(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(l *log.Logger) { Logger = l }(Logger)
+	Logger = log.New(&buf, "", 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	Run(ctx, exec.Command("true", "quiet-arg"))
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output when silent, got %q", buf.String())
+	}
+
+	ctx, cancel = context.WithTimeout(WithVerbosity(context.Background(), Debug), time.Millisecond*100)
+	defer cancel()
+	Run(ctx, exec.Command("true", "debug-arg"))
+	if !strings.Contains(buf.String(), "debug-arg") {
+		t.Fatalf("expected argv in debug output, got %q", buf.String())
+	}
+}
+
+func TestVerbosityFrom(t *testing.T) {
+	if v := VerbosityFrom(context.Background()); v != Silent {
+		t.Fatalf("expected Silent, got %v", v)
+	}
+	if v := VerbosityFrom(WithVerbosity(context.Background(), Errors)); v != Errors {
+		t.Fatalf("expected Errors, got %v", v)
+	}
+}
+
+func TestVerbosity_Labels(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(l *log.Logger) { Logger = l }(Logger)
+	Logger = log.New(&buf, "", 0)
+
+	ctx, cancel := context.WithTimeout(WithVerbosity(context.Background(), Debug), time.Millisecond*100)
+	defer cancel()
+	c := New(exec.Command("true"))
+	c.Labels = map[string]string{"tenant": "acme", "job": "etl"}
+	c.Run(ctx)
+	if !strings.Contains(buf.String(), "job=etl tenant=acme") {
+		t.Fatalf("expected labels in output, got %q", buf.String())
+	}
+}
+
+func TestSlog(t *testing.T) {
+	var buf bytes.Buffer
+	w := &lockedWriter{w: &buf}
+	c := New(exec.Command("bash", "-c", "sleep 5", "bash", "--token", "s3cr3t"), WithSlog(slog.New(slog.NewJSONHandler(w, nil))))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	c.Run(ctx)
+	<-c.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if strings.Contains(buf.String(), "s3cr3t") {
+		t.Fatalf("expected the secrets redacted, got %s", buf.String())
+	}
+	var msgs []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec["pid"] == nil {
+			t.Fatalf("expected a pid in %s", line)
+		}
+		msgs = append(msgs, rec["msg"].(string))
+		if rec["msg"] == "exit" {
+			if rec["exit_code"] == nil || rec["duration"] == nil || rec["command"] == nil {
+				t.Fatalf("expected the exit code, duration and command in %s", line)
+			}
+		}
+	}
+	// the process may be reaped before the signal that stopped it is logged
+	got := strings.Join(msgs, " ")
+	if len(msgs) < 3 || msgs[0] != "start" || !strings.Contains(got, "signal") || !strings.Contains(got, "exit") {
+		t.Fatalf("expected start, signal and exit records, got %v", msgs)
+	}
+}
@@ -0,0 +1,152 @@
+package ctxexec
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MarshalArgs turns a struct annotated with tags into command arguments,
+// so wrappers around complex programs can define typed options:
+//
+//	type RsyncOptions struct {
+//		Archive bool     `flag:"-a"`
+//		Exclude []string `flag:"--exclude,omitempty"`
+//		Bwlimit int      `flag:"--bwlimit,omitempty"`
+//		Src     string   `positional:"1"`
+//		Dst     string   `positional:"2"`
+//	}
+//
+// A flag tagged field is rendered as the flag followed by its value, a
+// bool one as the flag alone when true. With the equals option the flag
+// and its value form a single argument, as in --bwlimit=100. Zero values
+// are omitted with the omitempty option, nil pointers always are, and a
+// slice repeats the flag for each element.
+//
+// Positional fields follow the flags in the order of their index, slices
+// expanding to one argument per element. Fields of embedded structs are
+// marshaled in place, other fields are ignored.
+func MarshalArgs(v interface{}) ([]string, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("ctxexec: can't marshal %T as arguments, want a struct", v)
+	}
+	var m argMarshaler
+	if err := m.marshal(rv); err != nil {
+		return nil, err
+	}
+	sort.Stable(byIndex(m.positional))
+	args := m.flags
+	for _, p := range m.positional {
+		args = append(args, p.values...)
+	}
+	return args, nil
+}
+
+type argMarshaler struct {
+	flags      []string
+	positional []positionalArg
+}
+
+type positionalArg struct {
+	index  int
+	values []string
+}
+
+type byIndex []positionalArg
+
+func (p byIndex) Len() int           { return len(p) }
+func (p byIndex) Less(i, j int) bool { return p[i].index < p[j].index }
+func (p byIndex) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+func (m *argMarshaler) marshal(rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+		flag, hasFlag := field.Tag.Lookup("flag")
+		pos, hasPos := field.Tag.Lookup("positional")
+		if !hasFlag && !hasPos {
+			if field.Anonymous && fv.Kind() == reflect.Struct {
+				if err := m.marshal(fv); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			return fmt.Errorf("ctxexec: field %s is tagged but unexported", field.Name)
+		}
+		for fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				break
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Ptr {
+			continue // nil
+		}
+		values := argValues(fv)
+		if hasPos {
+			index, err := strconv.Atoi(pos)
+			if err != nil {
+				return fmt.Errorf("ctxexec: field %s: invalid positional index %q", field.Name, pos)
+			}
+			m.positional = append(m.positional, positionalArg{index, values})
+			continue
+		}
+		opts := strings.Split(flag, ",")
+		name, omitempty, equals := opts[0], false, false
+		for _, opt := range opts[1:] {
+			switch opt {
+			case "omitempty":
+				omitempty = true
+			case "equals":
+				equals = true
+			default:
+				return fmt.Errorf("ctxexec: field %s: unknown flag option %q", field.Name, opt)
+			}
+		}
+		switch {
+		case fv.Kind() == reflect.Bool:
+			if fv.Bool() {
+				m.flags = append(m.flags, name)
+			}
+		case omitempty && isZero(fv):
+		default:
+			for _, value := range values {
+				if equals {
+					m.flags = append(m.flags, name+"="+value)
+				} else {
+					m.flags = append(m.flags, name, value)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// argValues returns the values of a field as arguments
+func argValues(fv reflect.Value) []string {
+	if fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array {
+		values := make([]string, fv.Len())
+		for i := range values {
+			values[i] = fmt.Sprint(fv.Index(i).Interface())
+		}
+		return values
+	}
+	return []string{fmt.Sprint(fv.Interface())}
+}
+
+func isZero(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.Slice, reflect.Map:
+		return fv.Len() == 0
+	}
+	return reflect.DeepEqual(fv.Interface(), reflect.Zero(fv.Type()).Interface())
+}
@@ -0,0 +1,44 @@
+package ctxexec
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type commonOptions struct {
+	Verbose bool `flag:"-v"`
+}
+
+type rsyncOptions struct {
+	commonOptions
+	Archive bool          `flag:"-a"`
+	Exclude []string      `flag:"--exclude,omitempty"`
+	Bwlimit int           `flag:"--bwlimit,omitempty,equals"`
+	Timeout time.Duration `flag:"--timeout,omitempty"`
+	Port    *int          `flag:"--port"`
+	Dst     string        `positional:"2"`
+	Src     []string      `positional:"1"`
+	ignored string
+}
+
+func TestMarshalArgs(t *testing.T) {
+	args, err := MarshalArgs(&rsyncOptions{
+		commonOptions: commonOptions{Verbose: true},
+		Archive:       true,
+		Exclude:       []string{"*.tmp", ".git"},
+		Bwlimit:       100,
+		Src:           []string{"a/", "b/"},
+		Dst:           "host:dst",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"-v", "-a", "--exclude", "*.tmp", "--exclude", ".git", "--bwlimit=100", "a/", "b/", "host:dst"}
+	if !reflect.DeepEqual(args, want) {
+		t.Fatalf("expected %q, got %q", want, args)
+	}
+	if _, err := MarshalArgs("-a"); err == nil {
+		t.Fatal("expected an error for a non struct")
+	}
+}
@@ -0,0 +1,205 @@
+package ctxexec
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the run duration
+// histogram buckets of a Metrics without Buckets
+var DefaultBuckets = []float64{0.01, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 600, 1800, 3600}
+
+// Metrics collects the metrics of the commands it is set on and serves
+// them in the Prometheus text exposition format, without depending on the
+// Prometheus client:
+//
+//	ctxexec_commands_started_total     counter of the started commands
+//	ctxexec_commands_running           gauge of the commands not reaped yet
+//	ctxexec_command_duration_seconds   histogram of the run durations
+//	ctxexec_command_stops_total        counter of the stops, by mode, graceful or forced
+//	ctxexec_command_failures_total     counter of the unsuccessful exits
+//
+// The series are partitioned by the "command" label, the value of the
+// command's Label label, or the base name of its path. The command must be
+// reaped, by Wait or Done, for it to be accounted as exited.
+type Metrics struct {
+	// Label is the key of the command's Labels identifying it, "name"
+	// when empty
+	Label string
+	// Buckets are the upper bounds of the duration histogram buckets,
+	// DefaultBuckets when nil
+	Buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*commandMetrics
+}
+
+// commandMetrics are the metrics of the commands sharing a label value
+type commandMetrics struct {
+	started  uint64
+	running  int64
+	graceful uint64
+	forced   uint64
+	failures uint64
+	buckets  []uint64 // buckets counts the durations per bucket, not cumulated
+	count    uint64
+	sum      float64
+}
+
+// NewMetrics returns a new Metrics partitioned by the label
+func NewMetrics(label string) *Metrics {
+	return &Metrics{Label: label}
+}
+
+func (m *Metrics) buckets() []float64 {
+	if m.Buckets == nil {
+		return DefaultBuckets
+	}
+	return m.Buckets
+}
+
+// get returns the metrics of the command, m.mu must be held
+func (m *Metrics) get(c *CtxCmd) *commandMetrics {
+	label := m.Label
+	if label == "" {
+		label = "name"
+	}
+	name := c.Labels[label]
+	if name == "" {
+		name = filepath.Base(c.Path)
+	}
+	if m.series == nil {
+		m.series = map[string]*commandMetrics{}
+	}
+	s, ok := m.series[name]
+	if !ok {
+		s = &commandMetrics{buckets: make([]uint64, len(m.buckets()))}
+		m.series[name] = s
+	}
+	return s
+}
+
+// start accounts for the started command
+func (m *Metrics) start(c *CtxCmd) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.get(c)
+	s.started++
+	s.running++
+}
+
+// exit accounts for the reaped command
+func (m *Metrics) exit(c *CtxCmd) {
+	var duration time.Duration
+	if started := c.startedAt(); !started.IsZero() {
+		duration = time.Since(started)
+	}
+	// the process may be reaped before the stop signals are recorded
+	stopped := c.Status() == StatusStopping
+	killed := c.ProcessState != nil && exitSignal(c.ProcessState) == os.Kill
+	for _, e := range c.Timeline() {
+		switch e.Kind {
+		case EventSignal:
+			stopped = true
+		case EventKill:
+			killed = true
+		}
+	}
+	failed := c.exitError() != nil
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.get(c)
+	s.running--
+	switch {
+	case killed:
+		s.forced++
+	case stopped:
+		s.graceful++
+	}
+	if failed {
+		s.failures++
+	}
+	seconds := duration.Seconds()
+	for i, le := range m.buckets() {
+		if seconds <= le {
+			s.buckets[i]++
+			break
+		}
+	}
+	s.count++
+	s.sum += seconds
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	names := make([]string, 0, len(m.series))
+	for name := range m.series {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	series := make([]commandMetrics, len(names))
+	for i, name := range names {
+		series[i] = *m.series[name]
+		series[i].buckets = append([]uint64(nil), m.series[name].buckets...)
+	}
+	m.mu.Unlock()
+
+	var b strings.Builder
+	header := func(name, typ, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+	header("ctxexec_commands_started_total", "counter", "Number of started commands.")
+	for i, name := range names {
+		fmt.Fprintf(&b, "ctxexec_commands_started_total{command=%s} %d\n", quoteLabel(name), series[i].started)
+	}
+	header("ctxexec_commands_running", "gauge", "Number of commands started and not reaped yet.")
+	for i, name := range names {
+		fmt.Fprintf(&b, "ctxexec_commands_running{command=%s} %d\n", quoteLabel(name), series[i].running)
+	}
+	header("ctxexec_command_duration_seconds", "histogram", "Run duration of the commands.")
+	for i, name := range names {
+		s, q := series[i], quoteLabel(name)
+		var cumulated uint64
+		for j, le := range m.buckets() {
+			cumulated += s.buckets[j]
+			fmt.Fprintf(&b, "ctxexec_command_duration_seconds_bucket{command=%s,le=\"%s\"} %d\n", q, strconv.FormatFloat(le, 'g', -1, 64), cumulated)
+		}
+		fmt.Fprintf(&b, "ctxexec_command_duration_seconds_bucket{command=%s,le=\"+Inf\"} %d\n", q, s.count)
+		fmt.Fprintf(&b, "ctxexec_command_duration_seconds_sum{command=%s} %s\n", q, strconv.FormatFloat(s.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "ctxexec_command_duration_seconds_count{command=%s} %d\n", q, s.count)
+	}
+	header("ctxexec_command_stops_total", "counter", "Number of stopped commands, by mode.")
+	for i, name := range names {
+		fmt.Fprintf(&b, "ctxexec_command_stops_total{command=%s,mode=\"graceful\"} %d\n", quoteLabel(name), series[i].graceful)
+		fmt.Fprintf(&b, "ctxexec_command_stops_total{command=%s,mode=\"forced\"} %d\n", quoteLabel(name), series[i].forced)
+	}
+	header("ctxexec_command_failures_total", "counter", "Number of commands that exited unsuccessfully.")
+	for i, name := range names {
+		fmt.Fprintf(&b, "ctxexec_command_failures_total{command=%s} %d\n", quoteLabel(name), series[i].failures)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quoteLabel quotes a label value of the text exposition format
+func quoteLabel(v string) string {
+	return `"` + labelEscaper.Replace(v) + `"`
+}
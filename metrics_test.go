@@ -0,0 +1,43 @@
+package ctxexec
+
+import (
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestMetrics(t *testing.T) {
+	m := NewMetrics("job")
+	New(exec.Command("true"), WithMetrics(m), WithLabels(map[string]string{"job": "ok"})).Run(context.Background())
+	New(exec.Command("false"), WithMetrics(m), WithLabels(map[string]string{"job": "fail"})).Run(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	New(exec.Command("sleep", "5"), WithMetrics(m), WithGrace(time.Second)).Run(ctx)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`ctxexec_commands_started_total{command="ok"} 1`,
+		`ctxexec_commands_running{command="ok"} 0`,
+		`ctxexec_command_failures_total{command="fail"} 1`,
+		`ctxexec_command_failures_total{command="ok"} 0`,
+		`ctxexec_command_stops_total{command="sleep",mode="graceful"} 1`,
+		`ctxexec_command_duration_seconds_bucket{command="sleep",le="+Inf"} 1`,
+		`ctxexec_command_duration_seconds_count{command="ok"} 1`,
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Errorf("expected %s in\n%s", want, body)
+		}
+	}
+}
+
+func TestQuoteLabel(t *testing.T) {
+	if got := quoteLabel("a\"b\\c\n"); got != `"a\"b\\c\n"` {
+		t.Fatalf("unexpected %s", got)
+	}
+}
@@ -0,0 +1,74 @@
+package ctxexec
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// ErrCPULimitExceeded is the StopCause of commands stopped by their Monitor
+// for using more than MaxCPU
+var ErrCPULimitExceeded = errors.New("ctxexec: CPU limit exceeded")
+
+// Sample is a sample of the resource usage of a running process
+type Sample struct {
+	Time time.Time
+	RSS  uint64        // RSS is the resident memory in bytes
+	CPU  time.Duration // CPU is the user and system CPU time consumed so far
+	// CPUPercent is the CPU usage since the previous sample, in percent of
+	// one CPU, zero for the first sample
+	CPUPercent float64
+}
+
+// Monitor samples the resident memory and CPU usage of a running command,
+// from /proc on Linux and ps elsewhere. Its memory is limited by the
+// command's MaxMemory.
+type Monitor struct {
+	// Interval is the sampling interval, WatchInterval when zero
+	Interval time.Duration
+	// Tree includes the process descendants in the samples
+	Tree bool
+	// MaxCPU is the CPU usage between two samples, in percent of one CPU,
+	// over which the limit is exceeded, zero means no limit
+	MaxCPU float64
+	// OnSample is called with every sample, if set
+	OnSample func(Sample)
+	// OnExceeded is called with the sample and ErrCPULimitExceeded every
+	// time MaxCPU is exceeded. When nil the command is stopped instead,
+	// with the error as its StopCause.
+	OnExceeded func(Sample, error)
+}
+
+// monitor samples the usage of the process until it exits, see Monitor
+func (c *CtxCmd) monitor(ctx context.Context, m *Monitor) {
+	c.labelGoroutine()
+	interval := m.Interval
+	if interval <= 0 {
+		interval = WatchInterval
+	}
+	pid := c.Process.Pid
+	var prev Sample
+	c.watch(ctx, interval, func() (error, error) {
+		p, err := resourceUsage(pid, m.Tree)
+		if err != nil {
+			return nil, err
+		}
+		s := Sample{Time: time.Now(), RSS: p.rss, CPU: p.cpu}
+		if !prev.Time.IsZero() {
+			s.CPUPercent = 100 * float64(s.CPU-prev.CPU) / float64(s.Time.Sub(prev.Time))
+		}
+		prev = s
+		if m.OnSample != nil {
+			m.OnSample(s)
+		}
+		if m.MaxCPU <= 0 || s.CPUPercent <= m.MaxCPU {
+			return nil, nil
+		}
+		if m.OnExceeded != nil {
+			m.OnExceeded(s, ErrCPULimitExceeded)
+			return nil, nil
+		}
+		return ErrCPULimitExceeded, nil
+	})
+}
@@ -0,0 +1,56 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMonitor_MaxCPU(t *testing.T) {
+	c := New(exec.Command("bash", "-c", `while :; do :; done`), WithMonitor(Monitor{
+		Interval: time.Millisecond * 50,
+		MaxCPU:   50,
+	}))
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-c.reap():
+	case <-time.After(time.Second * 3):
+		c.Process.Kill()
+		t.Fatal("expected the command to be stopped over its CPU limit")
+	}
+	if err := c.StopCause(); err != ErrCPULimitExceeded {
+		t.Fatalf("expected %v, got %v", ErrCPULimitExceeded, err)
+	}
+}
+
+func TestMonitor_OnExceeded(t *testing.T) {
+	var mu sync.Mutex
+	var samples []Sample
+	var exceeded []error
+	c := New(exec.Command("timeout", "0.3", "bash", "-c", `while :; do :; done`), WithMonitor(Monitor{
+		Interval:   time.Millisecond * 20,
+		Tree:       true,
+		MaxCPU:     1,
+		OnSample:   func(s Sample) { mu.Lock(); samples = append(samples, s); mu.Unlock() },
+		OnExceeded: func(s Sample, err error) { mu.Lock(); exceeded = append(exceeded, err); mu.Unlock() },
+	}))
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	<-c.reap()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(samples) < 2 || samples[0].RSS == 0 {
+		t.Fatalf("expected samples of the memory usage, got %+v", samples)
+	}
+	if len(exceeded) < 2 || exceeded[0] != ErrCPULimitExceeded {
+		t.Fatalf("expected the callback to be called on every sample, got %v", exceeded)
+	}
+	if c.StopCause() != nil {
+		t.Fatal("expected the command not to be stopped with OnExceeded")
+	}
+}
@@ -0,0 +1,66 @@
+package ctxexec
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// muxColors are the ANSI colors of the prefixes, cycled through
+var muxColors = []string{"36", "33", "32", "35", "34", "31"}
+
+// Mux interleaves the output of concurrent commands onto one writer, line
+// by line, each line prefixed by the name of its command, like foreman
+type Mux struct {
+	// Color colors the prefixes with ANSI escape sequences, a different
+	// color for each command
+	Color bool
+
+	w     io.Writer
+	mu    sync.Mutex
+	width int            // width is the length of the longest name
+	color map[string]int // color is the color index of the names
+}
+
+// NewMux returns a new Mux writing to w
+func NewMux(w io.Writer) *Mux {
+	return &Mux{w: w, color: map[string]int{}}
+}
+
+// Attach writes the standard output and standard error of the command to
+// the Mux, prefixed by name. It sets the command's StdoutLines and
+// StderrLines and must be called before the command is started.
+func (m *Mux) Attach(name string, c *CtxCmd) {
+	m.mu.Lock()
+	if len(name) > m.width {
+		m.width = len(name)
+	}
+	if _, ok := m.color[name]; !ok {
+		m.color[name] = len(m.color)
+	}
+	m.mu.Unlock()
+	write := func(line string) { m.write(name, line) }
+	c.StdoutLines, c.StderrLines = write, write
+}
+
+// write writes the prefixed line
+func (m *Mux) write(name, line string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prefix := name + strings.Repeat(" ", m.width-len(name)) + " | "
+	if m.Color {
+		prefix = fmt.Sprintf("\x1b[%sm%s\x1b[0m", muxColors[m.color[name]%len(muxColors)], prefix)
+	}
+	io.WriteString(m.w, prefix+line+"\n")
+}
+
+// muxName returns the name of the i-th command of a group in its Mux, its
+// "name" label or the base name of its path followed by its index
+func muxName(c *CtxCmd, i int) string {
+	if name := c.Labels["name"]; name != "" {
+		return name
+	}
+	return fmt.Sprintf("%s.%d", filepath.Base(c.Path), i)
+}
@@ -0,0 +1,46 @@
+package ctxexec
+
+import (
+	"bytes"
+	"os/exec"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestMux(t *testing.T) {
+	var out bytes.Buffer
+	// web writes once echo is attached and the prefixes are padded to its name
+	g := &Group{Output: NewMux(&out)}
+	g.Go(func() *CtxCmd {
+		c := New(exec.Command("bash", "-c", "sleep 0.2; echo a; echo b >&2"))
+		c.Labels = map[string]string{"name": "web"}
+		return c
+	})
+	g.Go(func() *CtxCmd { return New(exec.Command("echo", "c")) })
+	if _, err := g.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	sort.Strings(lines)
+	want := []string{"echo.1 | c", "web    | a", "web    | b"}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected %q, got %q", want, lines)
+	}
+}
+
+func TestMux_Color(t *testing.T) {
+	var out bytes.Buffer
+	m := NewMux(&out)
+	m.Color = true
+	a, b := New(exec.Command("echo", "a")), New(exec.Command("echo", "b"))
+	m.Attach("a", a)
+	m.Attach("b", b)
+	a.Run(context.Background())
+	b.Run(context.Background())
+	if out.String() != "\x1b[36ma | \x1b[0ma\n\x1b[33mb | \x1b[0mb\n" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
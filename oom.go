@@ -0,0 +1,19 @@
+package ctxexec
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// checkOOMScoreAdj rejects the OOMScoreAdj on platforms without OOM scores
+// and scores out of range, before the command is started
+func checkOOMScoreAdj(score int) error {
+	if !oomScoreAdjSupported {
+		return errors.New("ctxexec: oom score adjustment isn't supported on " + runtime.GOOS)
+	}
+	if score < -1000 || score > 1000 {
+		return fmt.Errorf("ctxexec: invalid oom score adj %d, not within [-1000, 1000]", score)
+	}
+	return nil
+}
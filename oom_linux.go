@@ -0,0 +1,15 @@
+package ctxexec
+
+import (
+	"io/ioutil"
+	"strconv"
+)
+
+// oomScoreAdjSupported is true where setOOMScoreAdj is implemented
+const oomScoreAdjSupported = true
+
+// setOOMScoreAdj writes the oom_score_adj of the program, lowering it
+// below the one of its parent requires CAP_SYS_RESOURCE
+func setOOMScoreAdj(score int) error {
+	return ioutil.WriteFile("/proc/self/oom_score_adj", []byte(strconv.Itoa(score)), 0)
+}
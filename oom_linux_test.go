@@ -0,0 +1,30 @@
+package ctxexec
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestOOMScoreAdj(t *testing.T) {
+	c := New(exec.Command("sleep", "5"), WithOOMScoreAdj(500))
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	score, err := ioutil.ReadFile("/proc/" + strconv.Itoa(c.Process.Pid) + "/oom_score_adj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(score)) != "500" {
+		t.Fatalf("expected an oom_score_adj of 500, got %q", score)
+	}
+}
+
+func TestOOMScoreAdj_Invalid(t *testing.T) {
+	if err := New(exec.Command("true"), WithOOMScoreAdj(5000)).Start(); err == nil {
+		t.Fatal("expected a score out of range to be rejected")
+	}
+}
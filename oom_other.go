@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package ctxexec
+
+import "errors"
+
+// oomScoreAdjSupported is true where setOOMScoreAdj is implemented
+const oomScoreAdjSupported = false
+
+// setOOMScoreAdj returns an error, OOM scores are only supported on linux
+func setOOMScoreAdj(score int) error {
+	return errors.New("oom score adjustment isn't supported on this platform")
+}
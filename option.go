@@ -0,0 +1,184 @@
+package ctxexec
+
+import (
+	"log"
+	"log/slog"
+	"os"
+	"syscall"
+	"time"
+)
+
+// Option configures a CtxCmd created with New, as an alternative to setting
+// its fields
+type Option func(*CtxCmd)
+
+// WithStopFunc sets the StopFunc
+func WithStopFunc(f StopFunc) Option {
+	return func(c *CtxCmd) { c.StopFunc = f }
+}
+
+// WithEscalation sets the StopFunc to one climbing the ladder of the
+// policy, see Escalate
+func WithEscalation(p EscalationPolicy) Option {
+	return WithStopFunc(Escalate(p))
+}
+
+// WithStopSignal sets the StopSignals, sent in order to stop the command
+// gracefully
+func WithStopSignal(sigs ...os.Signal) Option {
+	return func(c *CtxCmd) { c.StopSignals = sigs }
+}
+
+// WithGrace sets the Grace, the time the command is given to exit
+// gracefully before it is killed
+func WithGrace(d time.Duration) Option {
+	return func(c *CtxCmd) { c.Grace = d }
+}
+
+// WithProcessGroup sets ProcessGroup, so the stop signals and the kill
+// reach the descendants of the command
+func WithProcessGroup() Option {
+	return func(c *CtxCmd) { c.ProcessGroup = true }
+}
+
+// WithDieWithParent sets DieWithParent, so the command doesn't outlive
+// the program
+func WithDieWithParent() Option {
+	return func(c *CtxCmd) { c.DieWithParent = true }
+}
+
+// WithForwardSignals sets the ForwardSignals, os.Interrupt, SIGTERM and
+// SIGHUP when none is given
+func WithForwardSignals(sigs ...os.Signal) Option {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP}
+	}
+	return func(c *CtxCmd) { c.ForwardSignals = sigs }
+}
+
+// WithStdoutLines sets StdoutLines, called with each line of the standard
+// output
+func WithStdoutLines(f func(line string)) Option {
+	return func(c *CtxCmd) { c.StdoutLines = f }
+}
+
+// WithStderrLines sets StderrLines, called with each line of the standard
+// error
+func WithStderrLines(f func(line string)) Option {
+	return func(c *CtxCmd) { c.StderrLines = f }
+}
+
+// WithPTY sets PTY, running the command attached to a pseudo-terminal
+func WithPTY() Option {
+	return func(c *CtxCmd) { c.PTY = true }
+}
+
+// WithLogger sets the Logger
+func WithLogger(l *log.Logger) Option {
+	return func(c *CtxCmd) { c.Logger = l }
+}
+
+// WithSlog sets the Slog logger receiving structured lifecycle records
+func WithSlog(l *slog.Logger) Option {
+	return func(c *CtxCmd) { c.Slog = l }
+}
+
+// WithTracer sets the Tracer opening a span around Run
+func WithTracer(t Tracer) Option {
+	return func(c *CtxCmd) { c.Tracer = t }
+}
+
+// WithMetrics sets the Metrics collecting the metrics of the command
+func WithMetrics(m *Metrics) Option {
+	return func(c *CtxCmd) { c.Metrics = m }
+}
+
+// WithMonitor sets the Monitor sampling the resource usage of the command
+func WithMonitor(m Monitor) Option {
+	return func(c *CtxCmd) { c.Monitor = &m }
+}
+
+// WithCgroup sets the Cgroup limits of the command
+func WithCgroup(l CgroupLimits) Option {
+	return func(c *CtxCmd) { c.Cgroup = &l }
+}
+
+// WithRlimit adds a resource limit of the process, such as
+// WithRlimit(syscall.RLIMIT_NOFILE, 1024, 4096)
+func WithRlimit(resource int, soft, hard uint64) Option {
+	return func(c *CtxCmd) { c.Rlimits = append(c.Rlimits, Rlimit{Resource: resource, Soft: soft, Hard: hard}) }
+}
+
+// WithNice sets the Nice value of the process, positive values lower its
+// priority
+func WithNice(n int) Option {
+	return func(c *CtxCmd) { c.Nice = n }
+}
+
+// WithIOPriority sets the IOPriority of the process
+func WithIOPriority(class IOClass, level int) Option {
+	return func(c *CtxCmd) { c.IOPriority = IOPriority{Class: class, Level: level} }
+}
+
+// WithCPUAffinity sets the CPUAffinity of the process
+func WithCPUAffinity(cpus ...int) Option {
+	return func(c *CtxCmd) { c.CPUAffinity = cpus }
+}
+
+// WithOOMScoreAdj sets the OOMScoreAdj of the process
+func WithOOMScoreAdj(score int) Option {
+	return func(c *CtxCmd) { c.OOMScoreAdj = score }
+}
+
+// WithLabels adds Labels
+func WithLabels(labels map[string]string) Option {
+	return func(c *CtxCmd) {
+		if c.Labels == nil {
+			c.Labels = map[string]string{}
+		}
+		for k, v := range labels {
+			c.Labels[k] = v
+		}
+	}
+}
+
+// WithMaxRuntime sets the MaxRuntime
+func WithMaxRuntime(d time.Duration) Option {
+	return func(c *CtxCmd) { c.MaxRuntime = d }
+}
+
+// WithStartTimeout sets the StartTimeout, bounding the time spent in
+// fork/exec and waiting for ReadyWhen independently of the run context
+func WithStartTimeout(d time.Duration) Option {
+	return func(c *CtxCmd) { c.StartTimeout = d }
+}
+
+// WithReadyWhen sets the ReadyWhen condition, which Start waits for
+func WithReadyWhen(cond Condition) Option {
+	return func(c *CtxCmd) { c.ReadyWhen = cond }
+}
+
+// WithGracefulStopOK sets GracefulStopOK
+func WithGracefulStopOK() Option {
+	return func(c *CtxCmd) { c.GracefulStopOK = true }
+}
+
+// WithRichErrors sets RichErrors
+func WithRichErrors() Option {
+	return func(c *CtxCmd) { c.RichErrors = true }
+}
+
+// WithSuccessExitCodes sets the SuccessExitCodes
+func WithSuccessExitCodes(codes ...int) Option {
+	return func(c *CtxCmd) { c.SuccessExitCodes = codes }
+}
+
+// WithPolicy sets the Policy
+func WithPolicy(p Policy) Option {
+	return func(c *CtxCmd) { c.Policy = p }
+}
+
+// WithInterceptors appends Interceptors
+func WithInterceptors(i ...Interceptor) Option {
+	return func(c *CtxCmd) { c.Interceptors = append(c.Interceptors, i...) }
+}
@@ -0,0 +1,29 @@
+package ctxexec
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestOptions(t *testing.T) {
+	c := New(exec.Command("true"),
+		WithStopSignal(syscall.SIGTERM),
+		WithGrace(time.Second*5),
+		WithLabels(map[string]string{"job": "etl"}),
+		WithSuccessExitCodes(1),
+	)
+	if !reflect.DeepEqual(c.StopSignals, []os.Signal{syscall.SIGTERM}) || c.Grace != time.Second*5 {
+		t.Fatalf("expected the stop options applied, got %v and %v", c.StopSignals, c.Grace)
+	}
+	if c.Labels["job"] != "etl" || !reflect.DeepEqual(c.SuccessExitCodes, []int{1}) {
+		t.Fatalf("expected the labels and exit codes applied, got %v and %v", c.Labels, c.SuccessExitCodes)
+	}
+	// options override the presets
+	if c := New(exec.Command("java"), WithGrace(time.Second)); c.Grace != time.Second {
+		t.Fatalf("expected the option to override the preset, got %v", c.Grace)
+	}
+}
@@ -0,0 +1,60 @@
+package ctxexec
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+
+	"golang.org/x/net/context"
+)
+
+// Output runs the command with Run and returns its standard output, like
+// exec.Cmd.Output but stopping the command when the context is done.
+//
+// If the command fails, the error is usually of type *exec.ExitError and,
+// when Stderr was nil, its Stderr holds the standard error of the command.
+func (c *CtxCmd) Output(ctx context.Context) ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("ctxexec: Stdout already set")
+	}
+	var stdout, stderr bytes.Buffer
+	c.Stdout = &stdout
+	captureErr := c.Stderr == nil
+	if captureErr {
+		c.Stderr = &stderr
+	}
+	err := c.Run(ctx)
+	if ee, ok := err.(*exec.ExitError); ok && captureErr {
+		ee.Stderr = stderr.Bytes()
+	}
+	return stdout.Bytes(), err
+}
+
+// CombinedOutput runs the command with Run and returns its combined
+// standard output and standard error, like exec.Cmd.CombinedOutput but
+// stopping the command when the context is done
+func (c *CtxCmd) CombinedOutput(ctx context.Context) ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("ctxexec: Stdout already set")
+	}
+	if c.Stderr != nil {
+		return nil, errors.New("ctxexec: Stderr already set")
+	}
+	var b bytes.Buffer
+	c.Stdout = &b
+	c.Stderr = &b
+	err := c.Run(ctx)
+	return b.Bytes(), err
+}
+
+// Output runs the *exec.Cmd with a default CtxCmd and returns its standard
+// output, see CtxCmd.Output
+func Output(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	return New(cmd).Output(ctx)
+}
+
+// CombinedOutput runs the *exec.Cmd with a default CtxCmd and returns its
+// combined standard output and standard error, see CtxCmd.CombinedOutput
+func CombinedOutput(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	return New(cmd).CombinedOutput(ctx)
+}
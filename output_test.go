@@ -0,0 +1,47 @@
+package ctxexec
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestOutput(t *testing.T) {
+	out, err := Output(context.Background(), exec.Command("bash", "-c", "echo out; echo err >&2; exit 2"))
+	if string(out) != "out\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+	ee, ok := err.(*exec.ExitError)
+	if !ok || string(ee.Stderr) != "err\n" {
+		t.Fatalf("expected an exit error with the standard error, got %#v", err)
+	}
+
+	c := New(exec.Command("echo"))
+	c.Stdout = &bytes.Buffer{}
+	if _, err := c.Output(context.Background()); err == nil {
+		t.Fatal("expected an error when Stdout is set")
+	}
+}
+
+func TestOutput_Cancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+	defer cancel()
+	start := time.Now()
+	out, err := Output(ctx, exec.Command("bash", "-c", "echo started; sleep 5"))
+	if err == nil || string(out) != "started\n" {
+		t.Fatalf("expected the output before the stop and an error, got %q, %v", out, err)
+	}
+	if time.Since(start) > time.Second*2 {
+		t.Fatal("expected the command to be stopped with the context")
+	}
+}
+
+func TestCombinedOutput(t *testing.T) {
+	out, err := CombinedOutput(context.Background(), exec.Command("bash", "-c", "echo out; echo err >&2"))
+	if err != nil || string(out) != "out\nerr\n" {
+		t.Fatalf("unexpected output %q, %v", out, err)
+	}
+}
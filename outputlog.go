@@ -0,0 +1,197 @@
+package ctxexec
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// OutputLog persists the output written to it in a file, so consumers can
+// stream it from any offset while the command keeps writing, for instance
+// to resume after a disconnection. It is safe for concurrent use, so the
+// same log can be used for Stdout and Stderr.
+type OutputLog struct {
+	path   string
+	mu     sync.Mutex
+	f      *os.File
+	size   int64
+	closed bool
+	notify chan struct{} // notify is closed on every write and on Close
+
+	onClose func() // onClose is called once the log is closed
+}
+
+// NewOutputLog returns an OutputLog persisting output to the file at
+// path, created or truncated
+func NewOutputLog(path string) (*OutputLog, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &OutputLog{path: path, f: f, notify: make(chan struct{})}, nil
+}
+
+// Write implements io.Writer
+func (l *OutputLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return 0, os.ErrClosed
+	}
+	n, err := l.f.Write(p)
+	l.size += int64(n)
+	l.broadcast()
+	return n, err
+}
+
+// Close marks the output complete, streams end once they read all of it
+func (l *OutputLog) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	l.broadcast()
+	err := l.f.Close()
+	l.mu.Unlock()
+	if l.onClose != nil {
+		l.onClose()
+	}
+	return err
+}
+
+// broadcast wakes the streams, l.mu must be held
+func (l *OutputLog) broadcast() {
+	close(l.notify)
+	l.notify = make(chan struct{})
+}
+
+// Size returns the number of bytes written so far
+func (l *OutputLog) Size() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.size
+}
+
+// Stream returns a reader of the output from the offset. Reads wait for
+// more output until the log is closed, when they return io.EOF, or the
+// context is done, when they return its error.
+func (l *OutputLog) Stream(ctx context.Context, from int64) (io.ReadCloser, error) {
+	f, err := os.Open(l.path)
+	if err != nil {
+		return nil, err
+	}
+	return &logReader{ctx: ctx, l: l, f: f, off: from}, nil
+}
+
+type logReader struct {
+	ctx context.Context
+	l   *OutputLog
+	f   *os.File
+	off int64
+}
+
+func (r *logReader) Read(p []byte) (int, error) {
+	for {
+		r.l.mu.Lock()
+		size, closed, notify := r.l.size, r.l.closed, r.l.notify
+		r.l.mu.Unlock()
+		if r.off < size {
+			if int64(len(p)) > size-r.off {
+				p = p[:size-r.off]
+			}
+			n, err := r.f.ReadAt(p, r.off)
+			r.off += int64(n)
+			if err == io.EOF {
+				err = nil
+			}
+			return n, err
+		}
+		if closed {
+			return 0, io.EOF
+		}
+		select {
+		case <-notify:
+		case <-r.ctx.Done():
+			return 0, r.ctx.Err()
+		}
+	}
+}
+
+func (r *logReader) Close() error {
+	return r.f.Close()
+}
+
+// OutputStore keeps the OutputLog of jobs by id in a directory, so their
+// output can be streamed by id while they run and after they completed
+type OutputStore struct {
+	Dir string // Dir is where the logs are persisted, as <id>.log
+
+	mu   sync.Mutex
+	live map[string]*OutputLog
+}
+
+// Create returns a new OutputLog for the job. Close it once the job is
+// done, its output is then streamed from its file.
+func (s *OutputStore) Create(id string) (*OutputLog, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	l, err := NewOutputLog(path)
+	if err != nil {
+		return nil, err
+	}
+	l.onClose = func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.live[id] == l {
+			delete(s.live, id)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.live == nil {
+		s.live = map[string]*OutputLog{}
+	}
+	s.live[id] = l
+	return l, nil
+}
+
+// Stream returns a reader of the job's output from the offset, following
+// the output of running jobs as OutputLog.Stream does. Logs not created
+// by this store, such as before a restart, are read as complete.
+func (s *OutputStore) Stream(ctx context.Context, id string, from int64) (io.ReadCloser, error) {
+	s.mu.Lock()
+	l, ok := s.live[id]
+	s.mu.Unlock()
+	if ok {
+		return l.Stream(ctx, from)
+	}
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(from, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (s *OutputStore) path(id string) (string, error) {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\`) {
+		return "", errors.New("ctxexec: invalid output id " + id)
+	}
+	return filepath.Join(s.Dir, id+".log"), nil
+}
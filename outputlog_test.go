@@ -0,0 +1,85 @@
+package ctxexec
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestOutputStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ctxexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := &OutputStore{Dir: dir}
+	l, err := store.Create("job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New(exec.Command("bash", "-c", `echo first; sleep 0.2; echo second`))
+	c.Stdout = l
+	c.Cleanup(func(context.Context) { l.Close() })
+	c.Start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+	for l.Size() == 0 {
+		time.Sleep(time.Millisecond * 10)
+	}
+	// a consumer reconnecting after reading "first\n"
+	r, err := store.Stream(ctx, "job", 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil || string(out) != "second\n" {
+		t.Fatalf("expected the output from the offset, got %q, %v", out, err)
+	}
+
+	store = &OutputStore{Dir: dir}
+	r, err = store.Stream(ctx, "job", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ = ioutil.ReadAll(r)
+	r.Close()
+	if string(out) != "first\nsecond\n" {
+		t.Fatalf("expected the persisted output, got %q", out)
+	}
+	if _, err := store.Create("../job"); err == nil {
+		t.Fatal("expected an invalid id to be rejected")
+	}
+}
+
+func TestOutputStore_Closed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ctxexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := &OutputStore{Dir: dir}
+	l, err := store.Create("job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Write([]byte("done\n"))
+	l.Close()
+	if len(store.live) != 0 {
+		t.Fatal("expected the closed log to be forgotten")
+	}
+	r, err := store.Stream(context.Background(), "job", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := ioutil.ReadAll(r)
+	r.Close()
+	if string(out) != "done\n" {
+		t.Fatalf("expected the output read from the file, got %q", out)
+	}
+}
@@ -0,0 +1,18 @@
+package ctxexec
+
+import (
+	"syscall"
+
+	"golang.org/x/net/context"
+)
+
+// setDieWithParent makes the kernel kill the process once the program dies
+func (c *CtxCmd) setDieWithParent() {
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	c.SysProcAttr.Pdeathsig = syscall.SIGKILL
+}
+
+// watchParent has nothing to do on linux, the kernel kills the process
+func (c *CtxCmd) watchParent(ctx context.Context) {}
@@ -0,0 +1,16 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+)
+
+func TestDieWithParent(t *testing.T) {
+	c := New(exec.Command("true"), WithDieWithParent())
+	c.Start()
+	c.Cmd.Wait()
+	if c.SysProcAttr == nil || c.SysProcAttr.Pdeathsig != syscall.SIGKILL {
+		t.Fatalf("expected Pdeathsig to be set, got %+v", c.SysProcAttr)
+	}
+}
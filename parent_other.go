@@ -0,0 +1,35 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package ctxexec
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/net/context"
+)
+
+// setDieWithParent has nothing to do without Pdeathsig, see watchParent
+func (c *CtxCmd) setDieWithParent() {}
+
+// watchParent starts a watchdog polling the program and the process every
+// second, which kills the process, or its group with ProcessGroup, once the
+// program died. The watchdog runs in its own process group so it survives
+// the signals sent to the program's one.
+func (c *CtxCmd) watchParent(ctx context.Context) {
+	target := strconv.Itoa(c.Process.Pid)
+	if c.ProcessGroup {
+		target = "-" + target
+	}
+	w := exec.Command("sh", "-c", `while kill -0 $0 && kill -0 $1; do sleep 1; done 2>/dev/null; kill -0 $0 2>/dev/null || kill -9 -- $2 2>/dev/null`,
+		strconv.Itoa(os.Getpid()), strconv.Itoa(c.Process.Pid), target)
+	w.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := w.Start(); err != nil {
+		logf(withCmd(ctx, c), Errors, "pid %d: start parent watchdog: %v", c.Process.Pid, err)
+		return
+	}
+	go w.Wait()
+}
@@ -0,0 +1,124 @@
+package ctxexec
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/net/context"
+)
+
+// PipelineError is returned by a Pipeline when a stage fails
+type PipelineError struct {
+	Stage int   // Stage is the index of the first stage that failed
+	Err   error // Err is the error of the stage
+}
+
+func (e *PipelineError) Error() string {
+	return fmt.Sprintf("ctxexec: pipeline stage %d failed: %v", e.Stage, e.Err)
+}
+
+// Unwrap returns the error of the stage, for errors.As and errors.Is
+func (e *PipelineError) Unwrap() error {
+	return e.Err
+}
+
+// Pipeline connects the standard output of each stage to the standard
+// input of the next one, like a shell pipeline
+type Pipeline struct {
+	Stages []*CtxCmd
+}
+
+// NewPipeline returns a new Pipeline of the stages
+func NewPipeline(stages ...*CtxCmd) *Pipeline {
+	return &Pipeline{Stages: stages}
+}
+
+// Start connects the stages and starts them in order. If a stage fails to
+// start, the stages already started are stopped and a *PipelineError is
+// returned.
+func (p *Pipeline) Start(ctx context.Context) error {
+	var files []*os.File
+	for i := 0; i < len(p.Stages)-1; i++ {
+		if p.Stages[i].Stdout != nil {
+			return fmt.Errorf("ctxexec: pipeline stage %d: Stdout already set", i)
+		}
+		if p.Stages[i+1].Stdin != nil {
+			return fmt.Errorf("ctxexec: pipeline stage %d: Stdin already set", i+1)
+		}
+	}
+	for i := 0; i < len(p.Stages)-1; i++ {
+		r, w, err := os.Pipe()
+		if err != nil {
+			closeFiles(files)
+			return err
+		}
+		files = append(files, r, w)
+		p.Stages[i].Stdout = w
+		p.Stages[i].closeAfterStart = append(p.Stages[i].closeAfterStart, w)
+		p.Stages[i+1].Stdin = r
+		p.Stages[i+1].closeAfterStart = append(p.Stages[i+1].closeAfterStart, r)
+	}
+	for i, c := range p.Stages {
+		if err := c.StartContext(ctx); err != nil {
+			closeFiles(files)
+			for _, started := range p.Stages[:i] {
+				started.Close()
+			}
+			return &PipelineError{Stage: i, Err: err}
+		}
+	}
+	return nil
+}
+
+// Wait waits for all the stages to exit. When a stage fails or the context
+// is done, the stages still running are stopped as by Wait, given their
+// Grace, and the first failure is returned as a *PipelineError.
+//
+// A stage terminated by SIGPIPE, once the next one stopped reading, isn't
+// a failure, like in shell pipelines.
+func (p *Pipeline) Wait(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type exit struct {
+		stage int
+		err   error
+	}
+	exits := make(chan exit, len(p.Stages))
+	for i, c := range p.Stages {
+		go func(i int, c *CtxCmd) {
+			exits <- exit{i, c.Wait(ctx)}
+		}(i, c)
+	}
+	var first error
+	for range p.Stages {
+		e := <-exits
+		if e.err == nil || first != nil || brokenPipe(p.Stages[e.stage]) {
+			continue
+		}
+		first = &PipelineError{Stage: e.stage, Err: e.err}
+		cancel()
+	}
+	return first
+}
+
+// Run starts the pipeline and waits for it
+func (p *Pipeline) Run(ctx context.Context) error {
+	if err := p.Start(ctx); err != nil {
+		return err
+	}
+	return p.Wait(ctx)
+}
+
+// brokenPipe returns true if the process was terminated by SIGPIPE
+func brokenPipe(c *CtxCmd) bool {
+	_, sig := c.ExitCode()
+	return sig == syscall.SIGPIPE
+}
+
+// closeFiles closes the files
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
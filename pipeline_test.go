@@ -0,0 +1,69 @@
+package ctxexec
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestPipeline(t *testing.T) {
+	var out bytes.Buffer
+	last := New(exec.Command("tr", "a-z", "A-Z"))
+	last.Stdout = &out
+	p := NewPipeline(
+		New(exec.Command("bash", "-c", "echo hello; echo world")),
+		New(exec.Command("grep", "o")),
+		last,
+	)
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "HELLO\nWORLD\n" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+
+	// the producer is terminated by SIGPIPE once head exits
+	if err := NewPipeline(New(exec.Command("yes")), New(exec.Command("head", "-1"))).Run(context.Background()); err != nil {
+		t.Fatalf("expected a broken pipe not to fail the pipeline, got %v", err)
+	}
+}
+
+func TestPipeline_Failure(t *testing.T) {
+	p := NewPipeline(
+		New(exec.Command("sleep", "5")),
+		New(exec.Command("bash", "-c", "exit 3")),
+	)
+	start := time.Now()
+	err := p.Run(context.Background())
+	if pe, ok := err.(*PipelineError); !ok || pe.Stage != 1 {
+		t.Fatalf("expected stage 1 to fail, got %v", err)
+	}
+	if ee := (*exec.ExitError)(nil); !errors.As(err, &ee) || ee.ExitCode() != 3 {
+		t.Fatalf("expected the exit error of the stage, got %v", err)
+	}
+	if time.Since(start) > time.Second*2 {
+		t.Fatal("expected the other stages to be torn down")
+	}
+
+	p = NewPipeline(New(exec.Command("true")), New(exec.Command("/nonexistent")))
+	if err, ok := p.Run(context.Background()).(*PipelineError); !ok || err.Stage != 1 {
+		t.Fatalf("expected stage 1 to fail to start, got %v", err)
+	}
+}
+
+func TestPipeline_Cancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	p := NewPipeline(New(exec.Command("sleep", "5")), New(exec.Command("cat")))
+	start := time.Now()
+	if err := p.Run(ctx); err == nil {
+		t.Fatal("expected an error")
+	}
+	if time.Since(start) > time.Second*2 {
+		t.Fatal("expected the stages to be stopped with the context")
+	}
+}
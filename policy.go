@@ -0,0 +1,118 @@
+package ctxexec
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Policy constrains what commands may be executed, it is consulted
+// before every exec
+type Policy interface {
+	// Admit returns a *PolicyError to reject the command. Otherwise it
+	// may return a function, called once the process exited, to release
+	// what was reserved for the command.
+	Admit(cmd *exec.Cmd) (release func(), err error)
+}
+
+// DefaultPolicy is the Policy consulted for commands without a Policy
+var DefaultPolicy Policy
+
+// PolicyError is returned when a Policy rejects a command
+type PolicyError struct {
+	Args   []string // Args are the arguments of the rejected command
+	Reason string   // Reason describes why the command was rejected
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("ctxexec: policy rejected %q: %s", e.Args, e.Reason)
+}
+
+// Rules is a Policy based on binary names and argument patterns.
+//
+// Binaries are matched by their base name, or by path when the
+// rule contains a path separator.
+type Rules struct {
+	Allow         []string         // Allow are the only binaries allowed, all when empty
+	Deny          []string         // Deny are binaries that are never allowed
+	DenyArgs      []*regexp.Regexp // DenyArgs are patterns no argument may match
+	MaxConcurrent map[string]int   // MaxConcurrent limits concurrent processes per binary
+
+	mu      sync.Mutex
+	running map[string]int
+}
+
+// Admit implements Policy
+func (r *Rules) Admit(cmd *exec.Cmd) (func(), error) {
+	reject := func(format string, args ...interface{}) (func(), error) {
+		return nil, &PolicyError{Args: cmd.Args, Reason: fmt.Sprintf(format, args...)}
+	}
+	if len(r.Allow) > 0 && !matchBinary(r.Allow, cmd.Path) {
+		return reject("binary %s is not allowed", cmd.Path)
+	}
+	if matchBinary(r.Deny, cmd.Path) {
+		return reject("binary %s is denied", cmd.Path)
+	}
+	var args []string // args are empty when Args is, exec runs Path alone
+	if len(cmd.Args) > 1 {
+		args = cmd.Args[1:]
+	}
+	for _, arg := range args {
+		for _, re := range r.DenyArgs {
+			if re.MatchString(arg) {
+				return reject("argument %q matches %s", arg, re)
+			}
+		}
+	}
+	name := filepath.Base(cmd.Path)
+	max, ok := r.MaxConcurrent[name]
+	if !ok {
+		return nil, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running[name] >= max {
+		return reject("%d %s processes are already running", max, name)
+	}
+	if r.running == nil {
+		r.running = map[string]int{}
+	}
+	r.running[name]++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.mu.Lock()
+			r.running[name]--
+			r.mu.Unlock()
+		})
+	}, nil
+}
+
+// matchBinary returns true if path matches one of the binaries
+func matchBinary(binaries []string, path string) bool {
+	for _, b := range binaries {
+		if strings.ContainsRune(b, filepath.Separator) {
+			if filepath.Clean(b) == filepath.Clean(path) {
+				return true
+			}
+		} else if b == filepath.Base(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// admit consults the command's Policy, or DefaultPolicy, before exec
+func (c *CtxCmd) admit() (func(), error) {
+	p := c.Policy
+	if p == nil {
+		p = DefaultPolicy
+	}
+	if p == nil {
+		return nil, nil
+	}
+	return p.Admit(c.Cmd)
+}
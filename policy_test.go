@@ -0,0 +1,77 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"regexp"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestPolicy(t *testing.T) {
+	rules := &Rules{
+		Deny:          []string{"rm"},
+		DenyArgs:      []*regexp.Regexp{regexp.MustCompile(`^--exec`)},
+		MaxConcurrent: map[string]int{"sleep": 1},
+	}
+	for _, cmd := range []*exec.Cmd{
+		exec.Command("rm", "-rf", "/tmp/nothing"),
+		exec.Command("find", ".", "--exec", "true"),
+	} {
+		c := New(cmd)
+		c.Policy = rules
+		if err, ok := c.Start().(*PolicyError); !ok {
+			t.Fatalf("expected %q to be rejected, got %v", cmd.Args, err)
+		}
+	}
+
+	a := New(exec.Command("sleep", "5"))
+	a.Policy = rules
+	if err := a.Start(); err != nil {
+		t.Fatal(err)
+	}
+	b := New(exec.Command("sleep", "5"))
+	b.Policy = rules
+	if _, ok := b.Start().(*PolicyError); !ok {
+		t.Fatal("expected the second sleep to be rejected")
+	}
+	a.Close()
+	c := New(exec.Command("sleep", "0"))
+	c.Policy = rules
+	if err := c.Start(); err != nil {
+		t.Fatalf("expected the slot to be released, got %v", err)
+	}
+	WaitAll(context.Background(), c)
+}
+
+func TestPolicy_Intercepted(t *testing.T) {
+	c := New(exec.Command("rm", "-rf", "/tmp/nothing"))
+	c.Policy = &Rules{Deny: []string{"rm"}}
+	c.Interceptors = []Interceptor{Prefix("nice", "-n", "10")}
+	if err, ok := c.Start().(*PolicyError); !ok {
+		t.Fatalf("expected the intercepted command to be rejected, got %v", err)
+	}
+}
+
+func TestPolicy_NilArgs(t *testing.T) {
+	rules := &Rules{DenyArgs: []*regexp.Regexp{regexp.MustCompile(`^--exec`)}}
+	release, err := rules.Admit(&exec.Cmd{Path: "/bin/true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if release != nil {
+		release()
+	}
+}
+
+func TestPolicy_Rewritten(t *testing.T) {
+	c := New(exec.Command("echo", "hi"))
+	c.Policy = &Rules{Deny: []string{"rm"}}
+	c.Interceptors = []Interceptor{func(cmd *exec.Cmd) error {
+		cmd.Path, cmd.Args = "/bin/rm", []string{"rm", "-rf", "/tmp/nothing"}
+		return nil
+	}}
+	if err, ok := c.Start().(*PolicyError); !ok {
+		t.Fatalf("expected the rewritten command to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,353 @@
+package ctxexec
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Pool runs commands on a limited number of slots. Commands waiting for a
+// slot are queued per key, such as a tenant or user, and keys are served
+// in weighted round-robin, so a key submitting thousands of commands
+// can't starve the others.
+//
+// A Pool must not be copied after first use.
+type Pool struct {
+	Size    int            // Size is the number of slots, unlimited when zero or less
+	Weights map[string]int // Weights are the consecutive slots granted to a key per round, 1 when unset
+
+	mu       sync.Mutex
+	running  int
+	queues   map[string][]*poolWaiter
+	ring     []string // ring are the keys with waiters, the first one being served
+	credit   int      // credit is the slots left for the first key of the ring this round
+	cmds     map[*CtxCmd]*poolCmd
+	draining bool
+}
+
+// ErrDraining is returned for submissions to a Pool being drained
+var ErrDraining = errors.New("ctxexec: pool is draining")
+
+// poolCmd tracks a command started in a Pool
+type poolCmd struct {
+	started bool          // started is set once the command started, under the pool's mu
+	done    chan struct{} // done is closed once the command exited or failed to start
+	once    sync.Once
+	finish  func() // finish untracks the command and closes done
+}
+
+// QueueTimeoutError is returned when a command couldn't get a slot in
+// a Pool by its start deadline
+type QueueTimeoutError struct {
+	Key    string        // Key is the key the command was queued with
+	Waited time.Duration // Waited is how long the command was queued
+}
+
+func (e *QueueTimeoutError) Error() string {
+	return fmt.Sprintf("ctxexec: no slot for %q after waiting %v", e.Key, e.Waited)
+}
+
+// Timeout returns true, a QueueTimeoutError is a timeout
+func (e *QueueTimeoutError) Timeout() bool {
+	return true
+}
+
+type startByKey struct{}
+
+// WithStartBy returns a copy of the parent context carrying t as the time
+// commands must start by when queued in a Pool, for submissions that should
+// give up well before the context's deadline.
+func WithStartBy(parent context.Context, t time.Time) context.Context {
+	return context.WithValue(parent, startByKey{}, t)
+}
+
+// startBy returns the earliest of the context's deadline and the time
+// set with WithStartBy
+func startBy(ctx context.Context) (time.Time, bool) {
+	deadline, ok := ctx.Deadline()
+	if t, set := ctx.Value(startByKey{}).(time.Time); set && (!ok || t.Before(deadline)) {
+		deadline, ok = t, true
+	}
+	return deadline, ok
+}
+
+// poolWaiter is a queued slot request
+type poolWaiter struct {
+	n       int   // n is the number of slots requested
+	err     error // err is set when the waiter is rejected rather than granted
+	granted bool
+	ready   chan struct{}
+}
+
+// Acquire waits for a slot for the key and returns the function releasing
+// it. It returns a *QueueTimeoutError if no slot is available by the
+// context's deadline, or the time set with WithStartBy, and the context's
+// error if it is cancelled first.
+func (p *Pool) Acquire(ctx context.Context, key string) (func(), error) {
+	if err := p.acquire(ctx, key, 1); err != nil {
+		return nil, err
+	}
+	return p.releaser(), nil
+}
+
+// acquire waits for n slots for the key, reserved at once
+func (p *Pool) acquire(ctx context.Context, key string, n int) error {
+	p.mu.Lock()
+	if p.draining {
+		p.mu.Unlock()
+		return ErrDraining
+	}
+	if p.fits(n) && len(p.ring) == 0 {
+		p.running += n
+		p.mu.Unlock()
+		return nil
+	}
+	w := &poolWaiter{n: n, ready: make(chan struct{})}
+	if p.queues == nil {
+		p.queues = map[string][]*poolWaiter{}
+	}
+	if len(p.queues[key]) == 0 {
+		p.ring = append(p.ring, key)
+		if len(p.ring) == 1 {
+			p.credit = p.weight(key)
+		}
+	}
+	p.queues[key] = append(p.queues[key], w)
+	p.mu.Unlock()
+
+	queued := time.Now()
+	var expired <-chan time.Time
+	if deadline, ok := startBy(ctx); ok {
+		timer := time.NewTimer(deadline.Sub(queued))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	var err error
+	select {
+	case <-w.ready:
+		return w.err
+	case <-expired:
+		err = &QueueTimeoutError{Key: key, Waited: time.Since(queued)}
+	case <-ctx.Done():
+		err = ctx.Err()
+		if err == context.DeadlineExceeded {
+			err = &QueueTimeoutError{Key: key, Waited: time.Since(queued)}
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if w.granted {
+		p.running -= n
+	} else {
+		p.dequeue(key, w)
+	}
+	p.dispatch()
+	return err
+}
+
+// Start waits for a slot for the key, then starts the command with
+// StartContext. The slot is released once the process exited. If the pool
+// is drained while the command starts, it is killed and ErrDraining is
+// returned.
+func (p *Pool) Start(ctx context.Context, key string, c *CtxCmd) error {
+	release, err := p.Acquire(ctx, key)
+	if err != nil {
+		return err
+	}
+	pc := p.track(c)
+	c.onExit = append(c.onExit, release, pc.finish)
+	if err := c.StartContext(ctx); err != nil {
+		release()
+		pc.finish()
+		return err
+	}
+	if !p.markStarted(pc) {
+		kill(withCmd(ctx, c), c.Cmd)
+		return ErrDraining
+	}
+	return nil
+}
+
+// Run waits for a slot for the key, then runs the command as CtxCmd.Run
+// does
+func (p *Pool) Run(ctx context.Context, key string, c *CtxCmd) error {
+	ctx = withCmd(ctx, c)
+	if err := p.Start(ctx, key, c); err != nil {
+		return err
+	}
+	return c.Wait(ctx)
+}
+
+// Drain stops accepting submissions, failing them and the queued ones with
+// ErrDraining, then waits for the commands started in the pool to exit.
+// The commands still running when the context is done are stopped, each
+// given its Grace to exit, and Drain returns the context's error once
+// they exited. Commands granted a slot but still starting aren't waited
+// for, they are killed once started and fail with ErrDraining.
+//
+// Slots acquired directly with Acquire are not waited for.
+func (p *Pool) Drain(ctx context.Context) error {
+	p.mu.Lock()
+	p.draining = true
+	for _, q := range p.queues {
+		for _, w := range q {
+			w.err = ErrDraining
+			close(w.ready)
+		}
+	}
+	p.queues, p.ring = nil, nil
+	cmds := make(map[*CtxCmd]*poolCmd, len(p.cmds))
+	for c, pc := range p.cmds {
+		if pc.started {
+			cmds[c] = pc
+		}
+	}
+	p.mu.Unlock()
+
+	drained := true
+	for _, pc := range cmds {
+		select {
+		case <-pc.done:
+		case <-ctx.Done():
+			drained = false
+		}
+		if !drained {
+			break
+		}
+	}
+	if drained {
+		return nil
+	}
+	logf(ctx, Debug, "drain: %v, stopping the remaining commands", ctx.Err())
+	var wg sync.WaitGroup
+	for c := range cmds {
+		wg.Add(1)
+		go func(c *CtxCmd) {
+			defer wg.Done()
+			sctx, cancel := context.WithTimeout(detach(ctx), c.grace())
+			defer cancel()
+			c.Stop(sctx)
+		}(c)
+	}
+	wg.Wait()
+	for _, pc := range cmds {
+		<-pc.done
+	}
+	return ctx.Err()
+}
+
+// markStarted records the command started, unless the pool is draining
+// in which case it returns false and the command must be killed
+func (p *Pool) markStarted(pc *poolCmd) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.draining {
+		return false
+	}
+	pc.started = true
+	return true
+}
+
+// track records a command about to be started in the pool
+func (p *Pool) track(c *CtxCmd) *poolCmd {
+	pc := &poolCmd{done: make(chan struct{})}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmds == nil {
+		p.cmds = map[*CtxCmd]*poolCmd{}
+	}
+	p.cmds[c] = pc
+	pc.finish = func() {
+		pc.once.Do(func() {
+			p.mu.Lock()
+			delete(p.cmds, c)
+			p.mu.Unlock()
+			close(pc.done)
+		})
+	}
+	return pc
+}
+
+// releaser returns a function releasing a slot once
+func (p *Pool) releaser() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.running--
+			p.dispatch()
+		})
+	}
+}
+
+// weight returns the key's weight, p.mu must be held
+func (p *Pool) weight(key string) int {
+	if w := p.Weights[key]; w > 0 {
+		return w
+	}
+	return 1
+}
+
+// fits returns true if n more slots are available, p.mu must be held
+func (p *Pool) fits(n int) bool {
+	return p.Size <= 0 || p.running+n <= p.Size
+}
+
+// dispatch grants the free slots to the queued waiters in turn, a waiter
+// for several slots holds back the ones after it. p.mu must be held
+func (p *Pool) dispatch() {
+	for len(p.ring) > 0 {
+		key := p.ring[0]
+		q := p.queues[key]
+		w := q[0]
+		if !p.fits(w.n) {
+			return
+		}
+		p.queues[key] = q[1:]
+		w.granted = true
+		close(w.ready)
+		p.running += w.n
+		p.credit--
+		switch {
+		case len(p.queues[key]) == 0:
+			delete(p.queues, key)
+			p.ring = p.ring[1:]
+		case p.credit <= 0:
+			p.ring = append(p.ring[1:], key)
+		default:
+			continue
+		}
+		if len(p.ring) > 0 {
+			p.credit = p.weight(p.ring[0])
+		}
+	}
+}
+
+// dequeue removes a waiter that gave up, p.mu must be held
+func (p *Pool) dequeue(key string, w *poolWaiter) {
+	q := p.queues[key]
+	for i := range q {
+		if q[i] == w {
+			q = append(q[:i], q[i+1:]...)
+			break
+		}
+	}
+	if len(q) > 0 {
+		p.queues[key] = q
+		return
+	}
+	delete(p.queues, key)
+	for i, k := range p.ring {
+		if k == key {
+			p.ring = append(p.ring[:i], p.ring[i+1:]...)
+			if i == 0 && len(p.ring) > 0 {
+				p.credit = p.weight(p.ring[0])
+			}
+			break
+		}
+	}
+}
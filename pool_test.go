@@ -0,0 +1,140 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestPool_Fairness(t *testing.T) {
+	p := &Pool{Size: 1, Weights: map[string]int{"b": 2}}
+	ctx := context.Background()
+	hold, _ := p.Acquire(ctx, "")
+	order := make(chan string, 7)
+	enqueue := func(key string, n int) {
+		for i := 0; i < n; i++ {
+			go func(i int) {
+				release, err := p.Acquire(ctx, key)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				order <- key
+				release()
+			}(i)
+			// queue in a deterministic order
+			time.Sleep(time.Millisecond * 10)
+		}
+	}
+	enqueue("a", 4)
+	enqueue("b", 2)
+	enqueue("c", 1)
+	hold()
+	var got string
+	for i := 0; i < 7; i++ {
+		got += <-order
+	}
+	if want := "abbcaaa"; got != want {
+		t.Fatalf("expected grants %s, got %s", want, got)
+	}
+}
+
+func TestPool_Cancel(t *testing.T) {
+	p := &Pool{Size: 1}
+	hold, _ := p.Acquire(context.Background(), "a")
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*20, cancel)
+	if _, err := p.Acquire(ctx, "b"); err != context.Canceled {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+	hold()
+	release, err := p.Acquire(context.Background(), "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if p.running != 0 || len(p.ring) != 0 {
+		t.Fatalf("expected an idle pool, got %d running and queued keys %v", p.running, p.ring)
+	}
+}
+
+func TestPool_StartBy(t *testing.T) {
+	p := &Pool{Size: 1}
+	hold, _ := p.Acquire(context.Background(), "a")
+	defer hold()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := p.Acquire(WithStartBy(ctx, time.Now().Add(time.Millisecond*20)), "b")
+	if qerr, ok := err.(*QueueTimeoutError); !ok || qerr.Key != "b" {
+		t.Fatalf("expected a *QueueTimeoutError for b, got %v", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	if _, err := p.Acquire(ctx, "c"); err == nil || !err.(*QueueTimeoutError).Timeout() {
+		t.Fatalf("expected a *QueueTimeoutError from the context deadline, got %v", err)
+	}
+}
+
+func TestPool_Drain(t *testing.T) {
+	p := &Pool{Size: 2}
+	ctx := context.Background()
+	quick := New(exec.Command("sleep", "0.05"))
+	slow := New(exec.Command("sleep", "5"))
+	p.Start(ctx, "", quick)
+	p.Start(ctx, "", slow)
+	queued := make(chan error)
+	go func() {
+		queued <- p.Start(ctx, "", New(exec.Command("true")))
+	}()
+	time.Sleep(time.Millisecond * 10)
+
+	dctx, cancel := context.WithTimeout(ctx, time.Millisecond*200)
+	defer cancel()
+	start := time.Now()
+	if err := p.Drain(dctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("expected the slow command to be stopped, drained in %v", d)
+	}
+	if err := <-queued; err != ErrDraining {
+		t.Fatalf("expected the queued command to be rejected, got %v", err)
+	}
+	if err := p.Start(ctx, "", New(exec.Command("true"))); err != ErrDraining {
+		t.Fatalf("expected %v, got %v", ErrDraining, err)
+	}
+	signalled := false
+	for _, e := range slow.Timeline() {
+		signalled = signalled || e.Kind == EventSignal
+	}
+	if !quick.stoppedGracefully() || !signalled {
+		t.Fatalf("expected the quick command to complete and the slow one to be stopped, got %v", slow.Timeline())
+	}
+}
+
+func TestPool_DrainStarting(t *testing.T) {
+	p := &Pool{Size: 1}
+	ctx := context.Background()
+	release := make(chan struct{})
+	c := New(exec.Command("sleep", "5"))
+	c.StartWhen = []Condition{func(ctx context.Context) error { <-release; return nil }}
+	started := make(chan error)
+	go func() { started <- p.Start(ctx, "", c) }()
+	time.Sleep(time.Millisecond * 50)
+
+	dctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := p.Drain(dctx); err != nil {
+		t.Fatalf("expected the starting command not to be waited for, got %v", err)
+	}
+	close(release)
+	if err := <-started; err != ErrDraining {
+		t.Fatalf("expected %v, got %v", ErrDraining, err)
+	}
+	<-c.reap()
+	if c.stoppedGracefully() {
+		t.Fatal("expected the command to be killed")
+	}
+}
@@ -0,0 +1,36 @@
+package ctxexec
+
+import (
+	"path/filepath"
+	"runtime/pprof"
+	"strconv"
+	"sync/atomic"
+
+	"golang.org/x/net/context"
+)
+
+// runs numbers the runs of the process, for their profile labels
+var runs uint64
+
+// setProfileLabels sets the labels of the goroutines spawned to manage the
+// command, such as the ones copying its I/O, to the labels of ctx with the
+// name of the command and a run id unique to the process, so profiles of
+// services that exec heavily attribute their cost to commands. The name is
+// the "name" label of the command or the base name of its path. The labels
+// of the calling goroutine are left alone.
+func (c *CtxCmd) setProfileLabels(ctx context.Context) {
+	name := c.Labels["name"]
+	if name == "" {
+		name = filepath.Base(c.Path)
+	}
+	run := strconv.FormatUint(atomic.AddUint64(&runs, 1), 10)
+	c.profileCtx = pprof.WithLabels(ctx, pprof.Labels("ctxexec_command", name, "ctxexec_run", run))
+}
+
+// labelGoroutine labels the calling goroutine, spawned to manage the
+// command, with the labels set by setProfileLabels
+func (c *CtxCmd) labelGoroutine() {
+	if c.profileCtx != nil {
+		pprof.SetGoroutineLabels(c.profileCtx)
+	}
+}
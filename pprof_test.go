@@ -0,0 +1,55 @@
+package ctxexec
+
+import (
+	"bytes"
+	"os/exec"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestProfileLabels(t *testing.T) {
+	c := New(exec.Command("sleep", "5"), WithLabels(map[string]string{"name": "labelled"}))
+	if err := c.StartContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.Done()
+
+	// the reaper is spawned in the background
+	var buf bytes.Buffer
+	for i := 0; i < 100; i++ {
+		buf.Reset()
+		pprof.Lookup("goroutine").WriteTo(&buf, 1)
+		if strings.Contains(buf.String(), `"ctxexec_command":"labelled"`) {
+			return
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	t.Fatalf("expected goroutines labelled with the command in\n%s", buf.String())
+}
+
+func TestProfileLabels_Caller(t *testing.T) {
+	defer pprof.SetGoroutineLabels(context.Background())
+	pprof.SetGoroutineLabels(pprof.WithLabels(context.Background(), pprof.Labels("caller", "kept")))
+	c := New(exec.Command("true"))
+	if err := c.StartContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	c.Wait(context.Background())
+
+	var buf bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&buf, 1)
+	for _, g := range strings.Split(buf.String(), "\n\n") {
+		if strings.Contains(g, "TestProfileLabels_Caller") {
+			if !strings.Contains(g, `"caller":"kept"`) || strings.Contains(g, "ctxexec_command") {
+				t.Fatalf("expected the labels of the caller to be left alone, got\n%s", g)
+			}
+			return
+		}
+	}
+	t.Fatalf("expected the test goroutine in\n%s", buf.String())
+}
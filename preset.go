@@ -0,0 +1,85 @@
+package ctxexec
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Preset is the recommended way of stopping a binary
+type Preset struct {
+	StopFunc    StopFunc      // StopFunc replaces the default StopFunc when set
+	StopSignals []os.Signal   // StopSignals replace the default stop signals when set
+	Grace       time.Duration // Grace replaces the default grace when set
+}
+
+var presetsMu sync.RWMutex
+
+// presets are the stop presets by binary name
+var presets = map[string]Preset{
+	"nginx":    {StopFunc: StopCommand("-s", "quit")},
+	"postgres": {StopSignals: []os.Signal{os.Interrupt}}, // fast shutdown
+	"java":     {StopSignals: []os.Signal{syscall.SIGTERM}, Grace: 30 * time.Second},
+}
+
+// SetPreset sets the stop preset New applies to commands running the
+// binary, matched by base name. A zero Preset removes it.
+func SetPreset(name string, p Preset) {
+	presetsMu.Lock()
+	defer presetsMu.Unlock()
+	if p.StopFunc == nil && len(p.StopSignals) == 0 && p.Grace == 0 {
+		delete(presets, name)
+		return
+	}
+	presets[name] = p
+}
+
+// LookupPreset returns the stop preset of the binary, matched by base name
+func LookupPreset(name string) (Preset, bool) {
+	presetsMu.RLock()
+	defer presetsMu.RUnlock()
+	p, ok := presets[filepath.Base(name)]
+	return p, ok
+}
+
+// applyPreset overrides the stop settings with the preset of the binary
+func (c *CtxCmd) applyPreset() {
+	p, ok := LookupPreset(c.Cmd.Path)
+	if !ok {
+		return
+	}
+	if p.StopFunc != nil {
+		c.StopFunc = p.StopFunc
+	}
+	if len(p.StopSignals) > 0 {
+		c.StopSignals = p.StopSignals
+	}
+	if p.Grace > 0 {
+		c.Grace = p.Grace
+	}
+}
+
+// StopCommand returns a StopFunc that runs the command's binary with args,
+// such as nginx -s quit, in the command's directory and environment. It
+// then waits for the process to exit, killing it when the context is done.
+// The stop command itself is killed when the context is done.
+func StopCommand(args ...string) StopFunc {
+	return func(ctx context.Context, cmd *exec.Cmd) error {
+		if cmd == nil || cmd.Process == nil {
+			return nil
+		}
+		stop := exec.CommandContext(ctx, cmd.Path, args...)
+		stop.Dir, stop.Env = cmd.Dir, cmd.Env
+		if err := stop.Run(); err != nil {
+			logf(ctx, Errors, "pid %d: %q: %v", cmd.Process.Pid, stop.Args, err)
+		} else {
+			logf(ctx, Debug, "pid %d: ran %q", cmd.Process.Pid, stop.Args)
+		}
+		return awaitExit(ctx, cmd)
+	}
+}
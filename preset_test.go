@@ -0,0 +1,56 @@
+package ctxexec
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestPreset(t *testing.T) {
+	c := New(exec.Command("/usr/bin/java", "-jar", "app.jar"))
+	if c.Grace != time.Second*30 || len(c.StopSignals) != 1 {
+		t.Fatalf("expected the java preset, got %v %v", c.Grace, c.StopSignals)
+	}
+
+	SetPreset("sleep", Preset{StopSignals: []os.Signal{os.Kill}})
+	defer SetPreset("sleep", Preset{})
+	c = New(exec.Command("sleep", "5"))
+	c.Grace = time.Millisecond * 100
+	c.Start()
+	if err := c.Close(); err == nil || !c.stopped() {
+		t.Fatalf("expected the preset stop signal, got %v", err)
+	}
+	if _, ok := LookupPreset("sleep"); !ok {
+		t.Fatal("expected the sleep preset")
+	}
+}
+
+func TestPreset_Wait(t *testing.T) {
+	SetPreset("sh", Preset{StopSignals: []os.Signal{syscall.SIGTERM}, Grace: time.Second * 2})
+	defer SetPreset("sh", Preset{})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+	defer cancel()
+	c := New(exec.Command("sh", "-c", `trap "sleep 0.3; exit 0" TERM; while true; do sleep 0.1; done`))
+	c.Start()
+	c.Wait(ctx)
+	if !c.ProcessState.Success() {
+		t.Fatalf("expected the preset grace once the context is done, got %v", c.ProcessState)
+	}
+}
+
+func TestStopCommand_Hung(t *testing.T) {
+	c := New(exec.Command("sh", "-c", `trap "" INT TERM; sleep 5`), WithStopFunc(StopCommand("-c", "sleep 5")))
+	c.Start()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+	defer cancel()
+	start := time.Now()
+	c.Stop(ctx)
+	if d := time.Since(start); d > time.Second*2 {
+		t.Fatalf("expected the hung stop command bounded by the context, took %v", d)
+	}
+	<-c.reap()
+}
@@ -0,0 +1,19 @@
+package ctxexec
+
+// IOClass is the I/O scheduling class of an IOPriority
+type IOClass int
+
+const (
+	// IOClassRealtime gets first access to the disk, it requires privileges
+	IOClassRealtime IOClass = iota + 1
+	// IOClassBestEffort is the default class
+	IOClassBestEffort
+	// IOClassIdle only gets disk time when no other process needs it
+	IOClassIdle
+)
+
+// IOPriority is the I/O scheduling priority of a process, as set by ionice
+type IOPriority struct {
+	Class IOClass
+	Level int // Level is the priority within the class, from 0, the highest, to 7
+}
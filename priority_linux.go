@@ -0,0 +1,20 @@
+package ctxexec
+
+import "syscall"
+
+const (
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+)
+
+// ioPrioritySupported is true where setIOPriority is implemented
+const ioPrioritySupported = true
+
+// setIOPriority sets the I/O priority of the calling thread
+func setIOPriority(p IOPriority) error {
+	prio := uintptr(p.Class)<<ioprioClassShift | uintptr(p.Level)
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, 0, prio); errno != 0 {
+		return errno
+	}
+	return nil
+}
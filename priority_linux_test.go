@@ -0,0 +1,22 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestIOPriority(t *testing.T) {
+	if _, err := exec.LookPath("ionice"); err != nil {
+		t.Skip("ionice unavailable")
+	}
+	out, err := New(exec.Command("ionice"), WithIOPriority(IOClassBestEffort, 6)).Output(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(out)) != "best-effort: prio 6" {
+		t.Fatalf("expected a best-effort priority of 6, got %q", out)
+	}
+}
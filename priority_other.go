@@ -0,0 +1,14 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package ctxexec
+
+import "errors"
+
+// ioPrioritySupported is true where setIOPriority is implemented
+const ioPrioritySupported = false
+
+// setIOPriority returns an error, I/O priorities are only supported on linux
+func setIOPriority(p IOPriority) error {
+	return errors.New("I/O priorities aren't supported on this platform")
+}
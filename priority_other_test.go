@@ -0,0 +1,17 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package ctxexec
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestIOPriority_Unsupported(t *testing.T) {
+	c := New(exec.Command("true"), WithIOPriority(IOClassBestEffort, 6))
+	if err := c.Start(); err == nil {
+		c.Close()
+		t.Fatal("expected the I/O priority to be rejected")
+	}
+}
@@ -0,0 +1,64 @@
+package ctxexec
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is the number of clock ticks per second of the CPU times of
+// /proc, USER_HZ, which is 100 on all the supported architectures
+const clockTicks = 100
+
+// processTable returns the processes of the system read from /proc
+func processTable() (map[int]procInfo, error) {
+	entries, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	pageSize := uint64(os.Getpagesize())
+	procs := map[int]procInfo{}
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := ioutil.ReadFile("/proc/" + e.Name() + "/stat")
+		if err != nil {
+			continue // the process exited
+		}
+		// the command name is in parentheses and may contain spaces
+		i := strings.LastIndexByte(string(stat), ')')
+		if i < 0 {
+			continue
+		}
+		// fields from the state on, ppid is the 4th field, utime and stime,
+		// in clock ticks, the 14th and 15th and rss, in pages, the 24th
+		fields := strings.Fields(string(stat[i+1:]))
+		if len(fields) < 22 {
+			continue
+		}
+		ppid, _ := strconv.Atoi(fields[1])
+		utime, _ := strconv.ParseUint(fields[11], 10, 64)
+		stime, _ := strconv.ParseUint(fields[12], 10, 64)
+		rss, _ := strconv.ParseUint(fields[21], 10, 64)
+		procs[pid] = procInfo{ppid: ppid, rss: rss * pageSize, cpu: time.Duration(utime+stime) * time.Second / clockTicks}
+	}
+	return procs, nil
+}
+
+// processArgs returns the command line of the process
+func processArgs(pid int) ([]string, error) {
+	cmdline, err := ioutil.ReadFile("/proc/" + strconv.Itoa(pid) + "/cmdline")
+	if err != nil {
+		return nil, err
+	}
+	var args []string
+	for _, arg := range bytes.Split(bytes.TrimSuffix(cmdline, []byte{0}), []byte{0}) {
+		args = append(args, string(arg))
+	}
+	return args, nil
+}
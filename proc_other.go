@@ -0,0 +1,63 @@
+//go:build !linux
+// +build !linux
+
+package ctxexec
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// processTable returns the processes of the system as listed by ps
+func processTable() (map[int]procInfo, error) {
+	out, err := exec.Command("ps", "-A", "-o", "pid=,ppid=,rss=,time=").Output()
+	if err != nil {
+		return nil, err
+	}
+	procs := map[int]procInfo{}
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 4 {
+			continue
+		}
+		pid, _ := strconv.Atoi(fields[0])
+		ppid, _ := strconv.Atoi(fields[1])
+		rss, _ := strconv.ParseUint(fields[2], 10, 64) // in KiB
+		procs[pid] = procInfo{ppid: ppid, rss: rss * 1024, cpu: parseCPUTime(fields[3])}
+	}
+	return procs, s.Err()
+}
+
+// parseCPUTime parses a CPU time as listed by ps, [[dd-]hh:]mm:ss[.ss]
+func parseCPUTime(s string) time.Duration {
+	var d time.Duration
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		days, _ := strconv.Atoi(s[:i])
+		d, s = time.Duration(days)*24*time.Hour, s[i+1:]
+	}
+	parts := strings.Split(s, ":")
+	for i, part := range parts {
+		unit := time.Second
+		for j := i; j < len(parts)-1; j++ {
+			unit *= 60
+		}
+		n, _ := strconv.ParseFloat(part, 64)
+		d += time.Duration(n * float64(unit))
+	}
+	return d
+}
+
+// processArgs returns the command line of the process as listed by ps,
+// the arguments can't be told apart
+func processArgs(pid int) ([]string, error) {
+	out, err := exec.Command("ps", "-o", "args=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(out)), nil
+}
@@ -0,0 +1,117 @@
+package ctxexec
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// Profiler collects a profile of a running command into a file
+type Profiler struct {
+	Path string // Path is the file the profile is stored in
+
+	done chan struct{}
+	err  error
+}
+
+// Wait waits for the profile to be stored and returns the error that
+// prevented it, if any
+func (p *Profiler) Wait() error {
+	<-p.done
+	return p.err
+}
+
+// AttachPerf profiles the running command with `perf record`, with the
+// extra arguments such as "-g", into the file at path. perf is stopped
+// cleanly, with SIGINT, once the command exits or the context is done.
+func (c *CtxCmd) AttachPerf(ctx context.Context, path string, args ...string) (*Profiler, error) {
+	if c.Process == nil {
+		return nil, fmt.Errorf("ctxexec: profiling a command that isn't started")
+	}
+	argv := append([]string{"record", "-o", path, "-p", strconv.Itoa(c.Process.Pid)}, args...)
+	perf := New(exec.Command("perf", argv...))
+	perf.StopSignals = []os.Signal{os.Interrupt}
+	if err := perf.StartContext(ctx); err != nil {
+		return nil, err
+	}
+	p := &Profiler{Path: path, done: make(chan struct{})}
+	go func() {
+		defer close(p.done)
+		select {
+		case <-perf.reap():
+		case <-c.reap():
+		case <-ctx.Done():
+		}
+		sctx, cancel := context.WithTimeout(context.Background(), perf.grace())
+		defer cancel()
+		perf.Stop(sctx)
+		<-perf.reap()
+		if err := perf.exitError(); err != nil && !perf.stoppedGracefully() {
+			p.err = fmt.Errorf("ctxexec: perf: %v", err)
+		}
+	}()
+	return p, nil
+}
+
+// AttachPprof fetches a profile from the debug endpoint of a running Go
+// command, such as http://localhost:6060/debug/pprof/profile?seconds=30,
+// into the file at path. The file is only created once the whole profile
+// is received, the request is aborted if the command exits or the context
+// is done first.
+func (c *CtxCmd) AttachPprof(ctx context.Context, url, path string) (*Profiler, error) {
+	if c.Process == nil {
+		return nil, fmt.Errorf("ctxexec: profiling a command that isn't started")
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	rctx, cancel := context.WithCancel(ctx)
+	var once sync.Once
+	p := &Profiler{Path: path, done: make(chan struct{})}
+	go func() {
+		select {
+		case <-c.reap():
+		case <-p.done:
+		}
+		once.Do(cancel)
+	}()
+	go func() {
+		defer close(p.done)
+		defer once.Do(cancel)
+		p.err = fetch(req.WithContext(rctx), path)
+	}()
+	return p, nil
+}
+
+// fetch stores the response body into the file at path, once complete
+func fetch(req *http.Request, path string) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ctxexec: %s: %s", req.URL, resp.Status)
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, resp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
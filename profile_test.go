@@ -0,0 +1,60 @@
+package ctxexec
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestAttachPprof(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ctxexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("seconds") == "" {
+			w.Write([]byte("profile"))
+			return
+		}
+		select {
+		case <-time.After(time.Second * 5):
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	c := New(exec.Command("sleep", "0.2"))
+	c.Start()
+	ctx := context.Background()
+	path := filepath.Join(dir, "heap.pprof")
+	p, err := c.AttachPprof(ctx, srv.URL+"/debug/pprof/heap", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := ioutil.ReadFile(path); string(data) != "profile" {
+		t.Fatalf("expected the profile stored, got %q", data)
+	}
+
+	path = filepath.Join(dir, "cpu.pprof")
+	p, err = c.AttachPprof(ctx, srv.URL+"/debug/pprof/profile?seconds=30", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Wait(); err == nil {
+		t.Fatal("expected the profile to be aborted when the command exits")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected no profile stored, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package ctxexec
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Terminal is the master side of the pseudo-terminal of a command run with
+// PTY, reading from it reads the output of the command and writing to it
+// types into the command
+type Terminal struct {
+	*os.File
+	copied chan struct{} // copied is closed once the output was copied to Stdout
+}
+
+// Terminal returns the pseudo-terminal of the started command, nil if it
+// doesn't run with PTY
+func (c *CtxCmd) Terminal() *Terminal {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.terminal
+}
+
+// openTerminal attaches the standard streams of the command to a new
+// pseudo-terminal. Stdin and Stdout, when set, are copied to and from the
+// terminal once the process started. It returns the function closing the
+// terminal once the process exited, after the output was copied to Stdout
+// for at most PipeCloseTimeout, or closing it if the process failed to
+// start.
+func (c *CtxCmd) openTerminal() (func(), error) {
+	master, slave, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+	t := &Terminal{File: master}
+	stdin, stdout := c.Stdin, c.Stdout
+	c.Stdin, c.Stdout, c.Stderr = slave, slave, slave
+	setControllingTerminal(c.Cmd)
+	c.closeAfterStart = append(c.closeAfterStart, slave)
+	if stdin != nil {
+		go func() {
+			c.labelGoroutine()
+			io.Copy(master, stdin)
+		}()
+	}
+	stopCopy := func() {}
+	if stdout != nil {
+		t.copied = make(chan struct{})
+		var ctx context.Context
+		ctx, stopCopy = context.WithCancel(context.Background())
+		go func() {
+			c.labelGoroutine()
+			// ends with EIO once the terminal is closed by the process
+			CopyContext(ctx, stdout, master)
+			close(t.copied)
+		}()
+	}
+	c.mu.Lock()
+	c.terminal = t
+	c.mu.Unlock()
+	return func() {
+		defer stopCopy()
+		if c.Process == nil {
+			slave.Close()
+			master.Close()
+			return
+		}
+		if t.copied == nil {
+			// the caller is given the time to read the remaining output
+			time.AfterFunc(PipeCloseTimeout, func() { master.Close() })
+			return
+		}
+		select {
+		case <-t.copied:
+		case <-time.After(PipeCloseTimeout): // grandchildren hold the terminal open
+			stopCopy() // closes the terminal
+			<-t.copied
+		}
+		master.Close()
+	}, nil
+}
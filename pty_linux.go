@@ -0,0 +1,80 @@
+package ctxexec
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+// openPTY opens a new pseudo-terminal and returns its master and slave sides
+func openPTY() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	var unlock int32
+	var n uint32
+	if err = ioctl(master, syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)); err == nil {
+		err = ioctl(master, syscall.TIOCGPTN, unsafe.Pointer(&n))
+	}
+	if err == nil {
+		slave, err = os.OpenFile("/dev/pts/"+strconv.Itoa(int(n)), os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	}
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
+
+// setControllingTerminal starts the command in a new session whose
+// controlling terminal is its standard input. The session leader already
+// leads its own process group, which setpgid would fail to create.
+func setControllingTerminal(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setpgid = false
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = 0
+}
+
+// winsize is the window size of a terminal
+type winsize struct {
+	rows, cols, x, y uint16
+}
+
+// SetSize sets the window size of the terminal, the command receives
+// SIGWINCH
+func (t *Terminal) SetSize(rows, cols int) error {
+	ws := winsize{rows: uint16(rows), cols: uint16(cols)}
+	return ioctl(t.File, syscall.TIOCSWINSZ, unsafe.Pointer(&ws))
+}
+
+// Size returns the window size of the terminal
+func (t *Terminal) Size() (rows, cols int, err error) {
+	var ws winsize
+	err = ioctl(t.File, syscall.TIOCGWINSZ, unsafe.Pointer(&ws))
+	return int(ws.rows), int(ws.cols), err
+}
+
+// ioctl calls the ioctl on the file, without switching it to blocking mode
+func ioctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	if err := rc.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg))
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package ctxexec
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestPTY(t *testing.T) {
+	var out bytes.Buffer
+	c := New(exec.Command("bash", "-c", `[ -t 0 ] && [ -t 1 ] && [ -t 2 ] && echo tty; stty size`), WithPTY())
+	c.Stdout = &out
+	c.Start()
+	if err := c.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "tty\r\n") {
+		t.Fatalf("expected the standard streams to be a terminal, got %q", out.String())
+	}
+}
+
+func TestPTY_ProcessGroup(t *testing.T) {
+	var out bytes.Buffer
+	c := New(exec.Command("bash", "-c", `sleep 5 & echo hi; wait`), WithPTY(), WithProcessGroup())
+	c.Stdout = &out
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 200)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	start := time.Now()
+	c.Wait(ctx)
+	if !strings.HasPrefix(out.String(), "hi") {
+		t.Fatalf("expected the output, got %q", out.String())
+	}
+	if d := time.Since(start); d > time.Second*2 {
+		t.Fatalf("expected the group stopped with the session leader, took %v", d)
+	}
+}
+
+func TestPTY_Interactive(t *testing.T) {
+	c := New(exec.Command("bash", "-c", `read line; echo "got $line"; stty size`), WithPTY())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	term := c.Terminal()
+	defer term.Close()
+	if err := term.SetSize(40, 100); err != nil {
+		t.Fatal(err)
+	}
+	if rows, cols, err := term.Size(); err != nil || rows != 40 || cols != 100 {
+		t.Fatalf("expected 40x100, got %dx%d, %v", rows, cols, err)
+	}
+	term.Write([]byte("ping\n"))
+	r := bufio.NewReader(term)
+	var lines []string
+	for len(lines) < 3 {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read %q: %v", lines, err)
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	if lines[0] != "ping" || lines[1] != "got ping" || lines[2] != "40 100" {
+		t.Fatalf("unexpected terminal output %q", lines)
+	}
+	if err := c.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPTY_StartFailure(t *testing.T) {
+	c := New(exec.Command("/nonexistent"), WithPTY())
+	if err := c.Start(); err == nil {
+		t.Fatal("expected a start failure")
+	}
+	if _, err := c.Terminal().Write([]byte("x")); err == nil {
+		t.Fatal("expected the terminal to be closed")
+	}
+}
+
+func TestPTY_Grandchild(t *testing.T) {
+	var out bytes.Buffer
+	// the grandchild ignores the SIGHUP sent once sh, the session leader, exits
+	c := New(exec.Command("sh", "-c", "trap '' HUP; sleep 5 & echo hi"), WithPTY())
+	c.Stdout = &out
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	start := time.Now()
+	c.Start()
+	c.Wait(ctx)
+	if d := time.Since(start); d > time.Second*2 {
+		t.Fatalf("expected Wait not to hang on the terminal held by the grandchild, took %v", d)
+	}
+	if !strings.HasPrefix(out.String(), "hi") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
@@ -0,0 +1,30 @@
+//go:build !linux
+// +build !linux
+
+package ctxexec
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+var errNoPTY = errors.New("ctxexec: pseudo-terminals aren't supported on this platform")
+
+// openPTY returns an error, pseudo-terminals are only supported on linux
+func openPTY() (master, slave *os.File, err error) {
+	return nil, nil, errNoPTY
+}
+
+// setControllingTerminal isn't reached, see openPTY
+func setControllingTerminal(cmd *exec.Cmd) {}
+
+// SetSize sets the window size of the terminal
+func (t *Terminal) SetSize(rows, cols int) error {
+	return errNoPTY
+}
+
+// Size returns the window size of the terminal
+func (t *Terminal) Size() (rows, cols int, err error) {
+	return 0, 0, errNoPTY
+}
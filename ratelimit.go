@@ -0,0 +1,97 @@
+package ctxexec
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// RateLimitPolicy is what a RateLimitWriter does with output over its rate
+type RateLimitPolicy int
+
+const (
+	// RateLimitBlock blocks writes until the rate allows them, which in
+	// turn blocks the command once its pipe buffer is full
+	RateLimitBlock RateLimitPolicy = iota
+	// RateLimitDrop discards the output over the rate
+	RateLimitDrop
+)
+
+// RateLimitWriter caps the throughput of output forwarded to an expensive
+// sink, such as a network log shipper, allowing bursts of up to a second
+// of output.
+//
+// It is safe for concurrent use, so the same writer can be used for
+// Stdout and Stderr.
+type RateLimitWriter struct {
+	w       io.Writer
+	rate    float64
+	policy  RateLimitPolicy
+	mu      sync.Mutex
+	tokens  float64 // tokens are the bytes that may be written now
+	last    time.Time
+	dropped int64
+}
+
+// NewRateLimitWriter returns a RateLimitWriter forwarding at most
+// bytesPerSec bytes per second to w. Zero or a negative rate means no
+// limit.
+func NewRateLimitWriter(w io.Writer, bytesPerSec int, policy RateLimitPolicy) *RateLimitWriter {
+	return &RateLimitWriter{
+		w:      w,
+		rate:   float64(bytesPerSec),
+		policy: policy,
+		tokens: float64(bytesPerSec),
+		last:   time.Now(),
+	}
+}
+
+// Write implements io.Writer, with RateLimitDrop it reports the dropped
+// bytes as written
+func (r *RateLimitWriter) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rate <= 0 {
+		return r.w.Write(p)
+	}
+	n := 0
+	for n < len(p) {
+		r.refill()
+		allowed := int(r.tokens)
+		if allowed == 0 {
+			if r.policy == RateLimitDrop {
+				r.dropped += int64(len(p) - n)
+				return len(p), nil
+			}
+			time.Sleep(time.Duration((1 - r.tokens) / r.rate * float64(time.Second)))
+			continue
+		}
+		if allowed > len(p)-n {
+			allowed = len(p) - n
+		}
+		m, err := r.w.Write(p[n : n+allowed])
+		r.tokens -= float64(m)
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// refill adds the tokens earned since the last write, r.mu must be held
+func (r *RateLimitWriter) refill() {
+	now := time.Now()
+	r.tokens += now.Sub(r.last).Seconds() * r.rate
+	if r.tokens > r.rate {
+		r.tokens = r.rate
+	}
+	r.last = now
+}
+
+// Dropped returns the number of bytes dropped with RateLimitDrop
+func (r *RateLimitWriter) Dropped() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dropped
+}
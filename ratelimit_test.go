@@ -0,0 +1,45 @@
+package ctxexec
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestRateLimitWriter_Block(t *testing.T) {
+	var out bytes.Buffer
+	r := NewRateLimitWriter(&out, 10000, RateLimitBlock)
+	c := New(exec.Command("head", "-c", "15000", "/dev/zero"))
+	c.Stdout = r
+	start := time.Now()
+	c.Start()
+	WaitAll(context.Background(), c)
+	if d := time.Since(start); d < time.Millisecond*400 {
+		t.Fatalf("expected the output to take about 500ms, took %v", d)
+	}
+	if out.Len() != 15000 {
+		t.Fatalf("expected all the output, got %d bytes", out.Len())
+	}
+}
+
+func TestRateLimitWriter_Drop(t *testing.T) {
+	var out bytes.Buffer
+	r := NewRateLimitWriter(&out, 10000, RateLimitDrop)
+	r.Write(make([]byte, 8000))
+	r.Write(make([]byte, 8000))
+	if out.Len() > 10100 || r.Dropped() < 5900 {
+		t.Fatalf("expected about 6000 bytes dropped, got %d written, %d dropped", out.Len(), r.Dropped())
+	}
+}
+
+func TestRateLimitWriter_Unlimited(t *testing.T) {
+	var out bytes.Buffer
+	r := NewRateLimitWriter(&out, 0, RateLimitDrop)
+	r.Write(make([]byte, 8000))
+	if out.Len() != 8000 || r.Dropped() != 0 {
+		t.Fatalf("expected no limit, got %d written, %d dropped", out.Len(), r.Dropped())
+	}
+}
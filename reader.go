@@ -0,0 +1,112 @@
+package ctxexec
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// ErrProcessExited is returned by the readers of StdoutReader and
+// StderrReader once the process exited and the buffered output was read
+var ErrProcessExited = errors.New("ctxexec: process exited")
+
+// ExitDrain is how long the readers of StdoutReader and StderrReader keep
+// reading once the process exited, for output still buffered in the pipe or
+// written by descendants
+var ExitDrain = 100 * time.Millisecond
+
+// StdoutReader returns a reader of the command's standard output that
+// returns promptly when the context is done, with the context's error, or
+// shortly after the process exited, with ErrProcessExited when the output
+// isn't closed by then, or with io.EOF if the command fails to start. It
+// must be called before the command is started.
+//
+// The command is reaped in the background, use Wait rather than Cmd.Wait on it.
+func (c *CtxCmd) StdoutReader(ctx context.Context) (io.ReadCloser, error) {
+	if c.Cmd.Stdout != nil {
+		return nil, errors.New("ctxexec: Stdout already set")
+	}
+	return c.pipeReader(ctx, &c.Cmd.Stdout)
+}
+
+// StderrReader is like StdoutReader for the command's standard error
+func (c *CtxCmd) StderrReader(ctx context.Context) (io.ReadCloser, error) {
+	if c.Cmd.Stderr != nil {
+		return nil, errors.New("ctxexec: Stderr already set")
+	}
+	return c.pipeReader(ctx, &c.Cmd.Stderr)
+}
+
+func (c *CtxCmd) pipeReader(ctx context.Context, w *io.Writer) (io.ReadCloser, error) {
+	if c.Cmd.Process != nil {
+		return nil, errors.New("ctxexec: reader requested after the process started")
+	}
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	*w = pw
+	c.closeAfterStart = append(c.closeAfterStart, pw)
+	// the reads are interrupted with the deadlines of rctx, done with ctx
+	// or ExitDrain after the process exited
+	rctx, cancel := context.WithCancel(ctx)
+	r := &ctxReader{f: pr, r: NewDeadlineReader(rctx, pr, 0), cancel: cancel}
+	exited := make(chan struct{})
+	c.onExit = append(c.onExit, func() { close(exited) })
+	c.onStartFailure = append(c.onStartFailure, func() { close(exited) })
+	go func() {
+		select {
+		case <-exited:
+		case <-rctx.Done():
+			return
+		}
+		timer := time.NewTimer(ExitDrain)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			r.interrupt(ErrProcessExited)
+		case <-rctx.Done():
+		}
+	}()
+	return r, nil
+}
+
+// ctxReader is a pipe reader interrupted with a read deadline
+type ctxReader struct {
+	f      *os.File
+	r      io.Reader // r reads f with the deadlines of the reader's context
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	err    error // err is the reason of the interruption, the context's error when unset
+}
+
+// interrupt cancels the reader's context, the reads then fail with err
+func (r *ctxReader) interrupt(err error) {
+	r.mu.Lock()
+	if r.err == nil {
+		r.err = err
+	}
+	r.mu.Unlock()
+	r.cancel()
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		r.mu.Lock()
+		if r.err != nil {
+			err = r.err
+		}
+		r.mu.Unlock()
+	}
+	return n, err
+}
+
+func (r *ctxReader) Close() error {
+	r.interrupt(os.ErrClosed)
+	return r.f.Close()
+}
@@ -0,0 +1,78 @@
+package ctxexec
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestStdoutReader(t *testing.T) {
+	c := New(exec.Command("bash", "-c", `echo one; echo two`))
+	r, err := c.StdoutReader(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	c.Start()
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "one\ntwo\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
+
+func TestStdoutReader_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := New(exec.Command("bash", "-c", `echo one; sleep 5`))
+	r, err := c.StdoutReader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer c.Close()
+	c.Start()
+	s := bufio.NewScanner(r)
+	if !s.Scan() || s.Text() != "one" {
+		t.Fatalf("expected the first line, got %q %v", s.Text(), s.Err())
+	}
+	time.AfterFunc(time.Millisecond*100, cancel)
+	start := time.Now()
+	if s.Scan() {
+		t.Fatalf("expected no more lines, got %q", s.Text())
+	}
+	if s.Err() != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", s.Err())
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("expected read to return promptly")
+	}
+}
+
+func TestStdoutReader_StartFailure(t *testing.T) {
+	c := New(exec.Command("true"))
+	c.Policy = &Rules{Deny: []string{"true"}}
+	r, err := c.StdoutReader(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if c.Start() == nil {
+		t.Fatal("expected the start to fail")
+	}
+	done := make(chan error, 1)
+	go func() { _, err := ioutil.ReadAll(r); done <- err }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected EOF, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the read to return once the start failed")
+	}
+}
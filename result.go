@@ -0,0 +1,125 @@
+package ctxexec
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// ResultTailBytes is the size of the tail of the standard output and
+// standard error kept by RunResult, unless captured with CaptureTail
+var ResultTailBytes = 64 << 10
+
+// Result summarizes a command run
+type Result struct {
+	Path     string            // Path is the resolved path of the command
+	Args     []string          // Args are the command line arguments
+	Labels   map[string]string // Labels are the command's Labels
+	ExitCode int               // ExitCode is the exit code, -1 if the process didn't exit or was terminated by a signal
+	Signal   os.Signal         // Signal is the signal that terminated the process, if any
+	Started  time.Time         // Started is when the process started, zero if it didn't
+	Duration time.Duration     // Duration is how long the process ran
+
+	// Stdout and Stderr are the tails captured with CaptureTail, Truncated
+	// is set if bytes were discarded from either
+	Stdout, Stderr []byte
+	Truncated      bool
+
+	// Compressed describes the output compressed with CompressOutput, nil
+	// without
+	Compressed *CompressedOutput
+
+	Stopped  bool  // Stopped is set if stop signals were sent to the process
+	Killed   bool  // Killed is set if the process was killed
+	Graceful bool  // Graceful is set if the process exited in response to the stop signals
+	Cause    error // Cause is the StopCause, if any
+
+	Timeline Timeline         // Timeline is the lifecycle events of the command
+	State    *os.ProcessState // State is the process state, nil if it didn't exit
+	Usage    *Usage           // Usage is the resource usage of the process, nil if it didn't exit
+}
+
+// RunResult runs the command with Run and returns its Result along with the
+// error returned by Run. The tails of the standard output and standard
+// error are captured, ResultTailBytes of each unless CaptureTail was called.
+func (c *CtxCmd) RunResult(ctx context.Context) (*Result, error) {
+	c.mu.Lock()
+	captured := c.tail[0] != nil
+	c.mu.Unlock()
+	if !captured {
+		c.CaptureTail(ResultTailBytes, 0)
+	}
+	err := c.Run(ctx)
+	return c.result(), err
+}
+
+// result returns the Result of the command as of now
+func (c *CtxCmd) result() *Result {
+	r := &Result{
+		Path:     c.Path,
+		Args:     c.Args,
+		Labels:   c.Labels,
+		ExitCode: -1,
+		Cause:    c.StopCause(),
+		Timeline: c.Timeline(),
+		State:    c.ProcessState,
+	}
+	var exited time.Time
+	for _, e := range r.Timeline {
+		switch e.Kind {
+		case EventStarted:
+			r.Started = e.Time
+		case EventSignal:
+			r.Stopped = true
+		case EventKill:
+			r.Stopped, r.Killed = true, true
+		case EventExit:
+			exited = e.Time
+		}
+	}
+	if !r.Started.IsZero() && !exited.IsZero() {
+		r.Duration = exited.Sub(r.Started)
+	}
+	if r.State != nil {
+		r.ExitCode, r.Signal = r.State.ExitCode(), exitSignal(r.State)
+		r.Usage = usageOf(r.State)
+		r.Graceful = r.Stopped && c.stoppedGracefully()
+	}
+	c.mu.Lock()
+	tail := c.tail
+	r.Compressed = c.compressed
+	c.mu.Unlock()
+	if tail[0] != nil {
+		r.Stdout, r.Truncated = tail[0].Bytes(), tail[0].Truncated()
+	}
+	if tail[1] != nil {
+		r.Stderr, r.Truncated = tail[1].Bytes(), r.Truncated || tail[1].Truncated()
+	}
+	return r
+}
+
+// ExitCode returns the exit code of the process and, if it was terminated
+// by a signal, the signal. The code is -1 while the process hasn't started,
+// is running or is being reaped, and when it was terminated by a signal.
+func (c *CtxCmd) ExitCode() (int, os.Signal) {
+	c.mu.Lock()
+	reaping, reaped := c.reaping, c.reaped
+	c.mu.Unlock()
+	if reaping && !reaped {
+		return -1, nil
+	}
+	if c.ProcessState == nil {
+		return -1, nil
+	}
+	return c.ProcessState.ExitCode(), exitSignal(c.ProcessState)
+}
+
+// exitSignal returns the signal that terminated the process, if any
+func exitSignal(state *os.ProcessState) os.Signal {
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return ws.Signal()
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestRunResult(t *testing.T) {
+	c := New(exec.Command("bash", "-c", "echo out; echo err >&2; exit 3"))
+	c.Labels = map[string]string{"job": "etl"}
+	r, err := c.RunResult(context.Background())
+	if err == nil {
+		t.Fatal("expected an exit error")
+	}
+	if r.ExitCode != 3 || r.Signal != nil || r.Stopped || r.Labels["job"] != "etl" {
+		t.Fatalf("unexpected result %+v", r)
+	}
+	if string(r.Stdout) != "out\n" || string(r.Stderr) != "err\n" || r.Truncated {
+		t.Fatalf("unexpected output %q, %q", r.Stdout, r.Stderr)
+	}
+	if r.Started.IsZero() || r.Duration <= 0 || !strings.HasSuffix(r.Path, "bash") {
+		t.Fatalf("expected the start time, duration and path, got %+v", r)
+	}
+}
+
+func TestRunResult_Stopped(t *testing.T) {
+	for _, test := range []struct {
+		run              string
+		graceful, killed bool
+	}{
+		{`exec sleep 5`, true, false},
+		{`trap "" SIGINT SIGTERM; sleep 5`, false, true},
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+		c := New(exec.Command("bash", "-c", test.run), WithGrace(time.Millisecond*300))
+		c.CaptureTail(1, 0)
+		r, _ := c.RunResult(ctx)
+		cancel()
+		if !r.Stopped || r.Graceful != test.graceful || r.Killed != test.killed {
+			t.Fatalf("%q: expected graceful %v and killed %v, got %+v", test.run, test.graceful, test.killed, r)
+		}
+		if r.ExitCode != -1 || r.Signal == nil {
+			t.Fatalf("%q: expected a termination signal, got %+v", test.run, r)
+		}
+		if test.killed && r.Signal != syscall.SIGKILL {
+			t.Fatalf("%q: expected SIGKILL, got %v", test.run, r.Signal)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	c := New(exec.Command("bash", "-c", "sleep 0.2; exit 5"))
+	if code, sig := c.ExitCode(); code != -1 || sig != nil {
+		t.Fatalf("expected -1 before the start, got %d, %v", code, sig)
+	}
+	c.Start()
+	if code, _ := c.ExitCode(); code != -1 {
+		t.Fatalf("expected -1 while running, got %d", code)
+	}
+	c.Wait(context.Background())
+	if code, sig := c.ExitCode(); code != 5 || sig != nil {
+		t.Fatalf("expected 5, got %d, %v", code, sig)
+	}
+
+	c = New(exec.Command("sleep", "5"))
+	c.Start()
+	c.Process.Signal(syscall.SIGTERM)
+	c.Wait(context.Background())
+	if code, sig := c.ExitCode(); code != -1 || sig != syscall.SIGTERM {
+		t.Fatalf("expected SIGTERM, got %d, %v", code, sig)
+	}
+}
+
+func TestRunResult_Usage(t *testing.T) {
+	// allocate about 64MiB and burn some CPU
+	c := New(exec.Command("bash", "-c", `x=$(head -c 67108864 /dev/zero | tr '\0' a); for i in $(seq 20000); do :; done`))
+	r, err := c.RunResult(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Usage == nil {
+		t.Fatal("expected the resource usage")
+	}
+	if r.Usage.MaxRSS < 32<<20 {
+		t.Fatalf("expected a max RSS of at least 32MiB, got %d", r.Usage.MaxRSS)
+	}
+	if r.Usage.UserTime+r.Usage.SystemTime <= 0 || r.Usage.MinorFaults <= 0 {
+		t.Fatalf("expected CPU time and page faults, got %+v", r.Usage)
+	}
+}
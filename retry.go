@@ -0,0 +1,47 @@
+package ctxexec
+
+import (
+	"math/rand"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// RetryPolicy configures RunRetry
+type RetryPolicy struct {
+	Attempts int                  // Attempts is the maximum number of runs, one when zero
+	Backoff  time.Duration        // Backoff is the delay before the second run, doubled before each next one
+	Jitter   time.Duration        // Jitter is a random duration up to which is added to each delay
+	RetryOn  func(err error) bool // RetryOn tells if the error is worth a retry, every error is when nil
+}
+
+// RunRetry runs the commands returned by newCmd, a command runs once, until
+// one succeeds or the policy tells to give up, and returns the error of the
+// last run. It doesn't retry when the context would be done before the next
+// run starts.
+func RunRetry(ctx context.Context, newCmd func() *CtxCmd, p RetryPolicy) error {
+	backoff := p.Backoff
+	for attempt := 1; ; attempt++ {
+		c := newCmd()
+		err := c.Run(ctx)
+		if err == nil || ctx.Err() != nil || attempt >= p.Attempts || (p.RetryOn != nil && !p.RetryOn(err)) {
+			return err
+		}
+		wait := backoff
+		if p.Jitter > 0 {
+			wait += time.Duration(rand.Int63n(int64(p.Jitter)))
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return err
+		}
+		logf(withCmd(ctx, c), Debug, "%q: attempt %d: %v, retrying in %v", c.Args, attempt, err, wait)
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		backoff *= 2
+	}
+}
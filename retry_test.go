@@ -0,0 +1,48 @@
+package ctxexec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestRunRetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ctxexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	count := filepath.Join(dir, "count")
+	// fails twice then succeeds
+	run := fmt.Sprintf(`echo x >> %s; [ $(wc -l < %s) -ge 3 ]`, count, count)
+	runs := 0
+	newCmd := func() *CtxCmd { runs++; return New(exec.Command("bash", "-c", run)) }
+	if err := RunRetry(context.Background(), newCmd, RetryPolicy{Attempts: 5, Backoff: time.Millisecond * 10}); err != nil || runs != 3 {
+		t.Fatalf("expected a success on the third run, got %d runs, %v", runs, err)
+	}
+
+	runs = 0
+	fail := func() *CtxCmd { runs++; return New(exec.Command("false")) }
+	if err := RunRetry(context.Background(), fail, RetryPolicy{Attempts: 3, Jitter: time.Millisecond}); err == nil || runs != 3 {
+		t.Fatalf("expected 3 failed runs, got %d, %v", runs, err)
+	}
+
+	runs = 0
+	never := func(error) bool { return false }
+	if RunRetry(context.Background(), fail, RetryPolicy{Attempts: 3, RetryOn: never}); runs != 1 {
+		t.Fatalf("expected RetryOn to stop the retries, got %d runs", runs)
+	}
+
+	runs = 0
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	if RunRetry(ctx, fail, RetryPolicy{Attempts: 3, Backoff: time.Second}); runs != 1 {
+		t.Fatalf("expected no retry past the deadline, got %d runs", runs)
+	}
+}
@@ -0,0 +1,166 @@
+package ctxexec
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+// RingBuffer is an io.Writer keeping only the last MaxBytes bytes and the
+// last MaxLines lines written to it, so capturing the output of long
+// running commands can't exhaust the memory. A trailing partial line counts
+// as a line. It is safe for concurrent use.
+type RingBuffer struct {
+	maxBytes   int
+	maxLines   int
+	mu         sync.Mutex
+	buf        []byte
+	lines      int    // lines counts the newlines in buf
+	truncated  bool   // truncated is set once bytes were discarded
+	onTruncate func() // onTruncate is called once bytes are first discarded
+}
+
+// NewRingBuffer returns a RingBuffer keeping the last maxBytes bytes and
+// maxLines lines, zero means no limit
+func NewRingBuffer(maxBytes, maxLines int) *RingBuffer {
+	return &RingBuffer{maxBytes: maxBytes, maxLines: maxLines}
+}
+
+// Write implements io.Writer
+func (r *RingBuffer) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := len(p)
+	if r.maxBytes > 0 && len(p) > r.maxBytes {
+		r.discard(len(r.buf))
+		p = p[len(p)-r.maxBytes:]
+		r.truncate()
+	}
+	r.buf = append(r.buf, p...)
+	r.lines += bytes.Count(p, []byte{'\n'})
+	if r.maxBytes > 0 && len(r.buf) > r.maxBytes {
+		r.discard(len(r.buf) - r.maxBytes)
+	}
+	for r.maxLines > 0 && r.countLines() > r.maxLines {
+		r.discard(bytes.IndexByte(r.buf, '\n') + 1)
+	}
+	// reclaim the space of the discarded bytes once they outweigh the kept ones
+	if cap(r.buf) > 2*len(r.buf)+512 {
+		r.buf = append([]byte(nil), r.buf...)
+	}
+	return n, nil
+}
+
+// discard drops the first n bytes
+func (r *RingBuffer) discard(n int) {
+	if n <= 0 {
+		return
+	}
+	r.lines -= bytes.Count(r.buf[:n], []byte{'\n'})
+	r.buf = r.buf[n:]
+	r.truncate()
+}
+
+// truncate marks the buffer truncated
+func (r *RingBuffer) truncate() {
+	if !r.truncated && r.onTruncate != nil {
+		r.onTruncate()
+	}
+	r.truncated = true
+}
+
+// countLines returns the number of lines, including a trailing partial one
+func (r *RingBuffer) countLines() int {
+	if len(r.buf) > 0 && r.buf[len(r.buf)-1] != '\n' {
+		return r.lines + 1
+	}
+	return r.lines
+}
+
+// Bytes returns a copy of the kept bytes
+func (r *RingBuffer) Bytes() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]byte(nil), r.buf...)
+}
+
+// String returns the kept bytes as a string
+func (r *RingBuffer) String() string {
+	return string(r.Bytes())
+}
+
+// Truncated returns true if bytes were discarded
+func (r *RingBuffer) Truncated() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.truncated
+}
+
+// CaptureTail captures the last maxBytes bytes and maxLines lines of the
+// command's standard output and standard error, in addition to the current
+// Stdout and Stderr if any. Zero means no limit. It must be called before
+// the command is started.
+func (c *CtxCmd) CaptureTail(maxBytes, maxLines int) (stdout, stderr *RingBuffer) {
+	stdout, stderr = NewRingBuffer(maxBytes, maxLines), NewRingBuffer(maxBytes, maxLines)
+	stdout.onTruncate = c.recordTruncated
+	stderr.onTruncate = c.recordTruncated
+	c.teeOutputs(stdout, stderr)
+	c.mu.Lock()
+	c.tail = [2]*RingBuffer{stdout, stderr}
+	c.mu.Unlock()
+	return stdout, stderr
+}
+
+func (c *CtxCmd) recordTruncated() {
+	c.timeline.record(EventOutputTruncated, nil)
+}
+
+// PipeCloseTimeout bounds how long Wait waits for the output pipes of a
+// command whose output is teed to close once the process exited, when
+// grandchildren still hold them open. Wait then closes them and returns
+// exec.ErrWaitDelay if the command otherwise succeeded. It is used unless
+// the Cmd's WaitDelay is set.
+var PipeCloseTimeout = time.Second
+
+// teeOutputs tees the command's Stdout and Stderr to the writers, nil ones
+// are skipped. The copy goroutines are shut down PipeCloseTimeout after the
+// process exited, so Wait never hangs on pipes held open by grandchildren.
+func (c *CtxCmd) teeOutputs(stdout, stderr io.Writer) {
+	if c.Cmd.WaitDelay == 0 {
+		c.Cmd.WaitDelay = PipeCloseTimeout
+	}
+	out, errw := c.Stdout, c.Stderr
+	if out != nil && interfaceEqual(out, errw) {
+		// the tees are written from two goroutines, unlike a shared writer
+		lw := &lockedWriter{w: out}
+		out, errw = lw, lw
+	}
+	if stdout != nil {
+		out = teeTo(out, stdout)
+	}
+	if stderr != nil {
+		errw = teeTo(errw, stderr)
+	}
+	c.Stdout, c.Stderr = out, errw
+}
+
+// teeTo returns a writer writing to w, if any, and to t
+func teeTo(w, t io.Writer) io.Writer {
+	if w == nil {
+		return t
+	}
+	return io.MultiWriter(w, t)
+}
+
+// lockedWriter serializes the writes to w
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
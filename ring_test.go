@@ -0,0 +1,66 @@
+package ctxexec
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestRingBuffer(t *testing.T) {
+	for _, test := range []struct {
+		maxBytes, maxLines int
+		writes             []string
+		want               string
+		truncated          bool
+	}{
+		{0, 0, []string{"a\n", "b"}, "a\nb", false},
+		{4, 0, []string{"ab", "cdef"}, "cdef", true},
+		{4, 0, []string{"abcdefgh"}, "efgh", true},
+		{0, 2, []string{"1\n2\n", "3\n4"}, "3\n4", true},
+		{0, 2, []string{"1\n2\n"}, "1\n2\n", false},
+		{5, 3, []string{"1\n2\n3\n4\n"}, "\n3\n4\n", true},
+	} {
+		r := NewRingBuffer(test.maxBytes, test.maxLines)
+		for _, w := range test.writes {
+			r.Write([]byte(w))
+		}
+		if r.String() != test.want || r.Truncated() != test.truncated {
+			t.Fatalf("%d bytes, %d lines, %q: expected %q, %v, got %q, %v", test.maxBytes, test.maxLines,
+				test.writes, test.want, test.truncated, r.String(), r.Truncated())
+		}
+	}
+}
+
+func TestCaptureTail(t *testing.T) {
+	c := New(exec.Command("bash", "-c", "for i in $(seq 1 1000); do echo $i; done; echo err >&2"))
+	var all bytes.Buffer
+	c.Stdout = &all
+	stdout, stderr := c.CaptureTail(0, 2)
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "999\n1000\n" || !stdout.Truncated() {
+		t.Fatalf("expected the last 2 lines, got %q", stdout.String())
+	}
+	if stderr.String() != "err\n" || stderr.Truncated() {
+		t.Fatalf("expected the whole standard error, got %q", stderr.String())
+	}
+	if bytes.Count(all.Bytes(), []byte{'\n'}) != 1000 {
+		t.Fatal("expected Stdout to receive the whole output")
+	}
+}
+
+func TestCaptureTail_SharedOutput(t *testing.T) {
+	c := New(exec.Command("bash", "-c", "for i in $(seq 1 100); do echo $i; echo $i >&2; done"))
+	var all bytes.Buffer
+	c.Stdout, c.Stderr = &all, &all
+	stdout, stderr := c.CaptureTail(0, 1)
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "100\n" || stderr.String() != "100\n" || all.Len() != 2*292 {
+		t.Fatalf("unexpected output %q, %q, %d bytes", stdout.String(), stderr.String(), all.Len())
+	}
+}
@@ -0,0 +1,10 @@
+package ctxexec
+
+// RlimitInfinity is the value of an unlimited resource limit
+const RlimitInfinity = ^uint64(0)
+
+// Rlimit is a resource limit of the process, such as RLIMIT_NOFILE
+type Rlimit struct {
+	Resource   int    // Resource is the resource, syscall.RLIMIT_NOFILE for instance
+	Soft, Hard uint64 // Soft and Hard are the soft and hard limits
+}
@@ -0,0 +1,12 @@
+//go:build freebsd || dragonfly
+// +build freebsd dragonfly
+
+package ctxexec
+
+import "syscall"
+
+// setrlimit sets the resource limit of the program, the limits are signed
+// with RlimitInfinity being -1
+func setrlimit(r Rlimit) error {
+	return syscall.Setrlimit(r.Resource, &syscall.Rlimit{Cur: int64(r.Soft), Max: int64(r.Hard)})
+}
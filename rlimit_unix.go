@@ -0,0 +1,11 @@
+//go:build !windows && !freebsd && !dragonfly
+// +build !windows,!freebsd,!dragonfly
+
+package ctxexec
+
+import "syscall"
+
+// setrlimit sets the resource limit of the program
+func setrlimit(r Rlimit) error {
+	return syscall.Setrlimit(r.Resource, &syscall.Rlimit{Cur: r.Soft, Max: r.Hard})
+}
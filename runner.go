@@ -0,0 +1,57 @@
+package ctxexec
+
+import (
+	"golang.org/x/net/context"
+)
+
+// Runner runs commands
+type Runner interface {
+	Run(ctx context.Context, c *CtxCmd) error
+}
+
+// RunnerFunc is an adapter to allow the use of ordinary functions as Runners
+type RunnerFunc func(ctx context.Context, c *CtxCmd) error
+
+// Run calls f(ctx, c)
+func (f RunnerFunc) Run(ctx context.Context, c *CtxCmd) error {
+	return f(ctx, c)
+}
+
+// DefaultRunner runs the command with CtxCmd.Run
+var DefaultRunner Runner = RunnerFunc(func(ctx context.Context, c *CtxCmd) error {
+	return c.Run(ctx)
+})
+
+// Middleware wraps a Runner to layer cross-cutting concerns, such as
+// logging, metrics or policy checks, the way HTTP middleware wraps handlers
+type Middleware func(Runner) Runner
+
+// Chain is a Runner that runs commands through a stack of middleware
+type Chain struct {
+	runner     Runner
+	middleware []Middleware
+}
+
+// NewChain returns a new Chain for the Runner, DefaultRunner if nil
+func NewChain(r Runner) *Chain {
+	if r == nil {
+		r = DefaultRunner
+	}
+	return &Chain{runner: r}
+}
+
+// Use appends middleware to the chain and returns the chain. The first
+// middleware used is the outermost one.
+func (ch *Chain) Use(mw ...Middleware) *Chain {
+	ch.middleware = append(ch.middleware, mw...)
+	return ch
+}
+
+// Run runs the command through the middleware and the chain's Runner
+func (ch *Chain) Run(ctx context.Context, c *CtxCmd) error {
+	r := ch.runner
+	for i := len(ch.middleware) - 1; i >= 0; i-- {
+		r = ch.middleware[i](r)
+	}
+	return r.Run(ctx, c)
+}
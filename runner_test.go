@@ -0,0 +1,40 @@
+package ctxexec
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Runner) Runner {
+			return RunnerFunc(func(ctx context.Context, c *CtxCmd) error {
+				calls = append(calls, name)
+				return next.Run(ctx, c)
+			})
+		}
+	}
+	errDenied := errors.New("denied")
+	deny := func(next Runner) Runner {
+		return RunnerFunc(func(ctx context.Context, c *CtxCmd) error {
+			return errDenied
+		})
+	}
+
+	c := New(exec.Command("true"))
+	err := NewChain(nil).Use(trace("a"), trace("b"), deny).Run(context.Background(), c)
+	if err != errDenied {
+		t.Fatalf("expected the innermost middleware error, got %v", err)
+	}
+	if !reflect.DeepEqual(calls, []string{"a", "b"}) {
+		t.Fatalf("expected middleware to run in order, got %v", calls)
+	}
+	if c.Cmd.Process != nil {
+		t.Fatal("expected the command not to run")
+	}
+}
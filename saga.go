@@ -0,0 +1,66 @@
+package ctxexec
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+)
+
+// Step is a command of a saga with an optional compensating command
+type Step struct {
+	Cmd      *CtxCmd // Cmd is the command of the step
+	Rollback *CtxCmd // Rollback undoes the step once it completed, optional
+}
+
+// SagaError is returned by RunSaga when a step fails
+type SagaError struct {
+	Step      int     // Step is the index of the failed step
+	Err       error   // Err is the error of the failed step
+	Rollbacks []error // Rollbacks are the errors of the rollback commands, in the order they ran
+}
+
+func (e *SagaError) Error() string {
+	return fmt.Sprintf("ctxexec: saga step %d failed: %v", e.Step, e.Err)
+}
+
+// Unwrap returns the error of the failed step, for errors.As and errors.Is
+func (e *SagaError) Unwrap() error {
+	return e.Err
+}
+
+// RunSaga runs the steps in order, each to completion. When a step fails
+// or the context is done, the rollback commands of the completed steps are
+// run in reverse order and a *SagaError is returned.
+//
+// Steps run until they exit or the context is done. Rollback commands run
+// with a context that isn't derived from ctx, bounded by their MaxRuntime
+// or by DefaultHookTimeout.
+func RunSaga(ctx context.Context, steps ...Step) error {
+	for i, step := range steps {
+		err := ctx.Err()
+		if err == nil {
+			err = runStep(ctx, step.Cmd)
+		}
+		if err == nil {
+			continue
+		}
+		serr := &SagaError{Step: i, Err: err}
+		for j := i - 1; j >= 0; j-- {
+			if rb := steps[j].Rollback; rb != nil {
+				logf(ctx, Debug, "saga step %d: rollback %q", j, rb.Args)
+				serr.Rollbacks = append(serr.Rollbacks, runHook(detach(ctx), rb))
+			}
+		}
+		return serr
+	}
+	return nil
+}
+
+// runStep runs a step to completion, unlike hooks it isn't bounded by
+// DefaultHookTimeout
+func runStep(ctx context.Context, c *CtxCmd) error {
+	if err := c.StartContext(ctx); err != nil {
+		return err
+	}
+	return waitExit(ctx, c)
+}
@@ -0,0 +1,49 @@
+package ctxexec
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestRunSaga(t *testing.T) {
+	var out bytes.Buffer
+	echo := func(s string) *CtxCmd {
+		cmd := exec.Command("echo", s)
+		cmd.Stdout = &out
+		return New(cmd)
+	}
+	err := RunSaga(context.Background(),
+		Step{Cmd: echo("a"), Rollback: echo("undo a")},
+		Step{Cmd: echo("b")},
+		Step{Cmd: echo("c"), Rollback: echo("undo c")},
+		Step{Cmd: New(exec.Command("false")), Rollback: echo("undo d")},
+		Step{Cmd: echo("e")},
+	)
+	serr, ok := err.(*SagaError)
+	if !ok || serr.Step != 3 {
+		t.Fatalf("expected step 3 to fail, got %v", err)
+	}
+	var ee *exec.ExitError
+	if !errors.As(err, &ee) {
+		t.Fatalf("expected the exit error of the step, got %v", err)
+	}
+	if len(serr.Rollbacks) != 2 {
+		t.Fatalf("expected 2 rollbacks, got %v", serr.Rollbacks)
+	}
+	if want := "a\nb\nc\nundo c\nundo a\n"; out.String() != want {
+		t.Fatalf("expected %q, got %q", want, out.String())
+	}
+}
+
+func TestRunSaga_LongStep(t *testing.T) {
+	defer func(d time.Duration) { DefaultHookTimeout = d }(DefaultHookTimeout)
+	DefaultHookTimeout = time.Millisecond * 50
+	if err := RunSaga(context.Background(), Step{Cmd: New(exec.Command("sleep", "0.3"))}); err != nil {
+		t.Fatalf("expected the step not to be bounded by DefaultHookTimeout, got %v", err)
+	}
+}
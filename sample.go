@@ -0,0 +1,116 @@
+package ctxexec
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Sampling configures a SampleWriter. Zero fields disable the corresponding
+// limit, lines matching Keep are always forwarded and don't count against
+// the limits.
+type Sampling struct {
+	Every     int            // Every forwards every Nth line
+	PerSecond int            // PerSecond forwards at most N lines per second
+	Keep      *regexp.Regexp // Keep matches lines that are never dropped
+}
+
+// SampleWriter forwards a sample of the lines written to it, keeping
+// progress-bar-spamming commands from overwhelming consumers and storage.
+// A line ends with "\n", "\r\n" or "\r", so carriage-return progress
+// updates are sampled like any other line.
+//
+// Close flushes a pending partial line. It is safe for concurrent use, so the
+// same writer can be used for Stdout and Stderr.
+type SampleWriter struct {
+	w      io.Writer
+	s      Sampling
+	mu     sync.Mutex
+	buf    []byte    // buf holds the partial line
+	n      int       // n counts the lines subject to sampling
+	window time.Time // window is the start of the current one second window
+	sent   int       // sent counts the lines forwarded within window
+	cr     bool      // cr is set when the last line ended with "\r", a "\n" may follow
+	kept   bool      // kept is set when the last line was forwarded
+}
+
+// NewSampleWriter returns a SampleWriter forwarding a sample to w
+func NewSampleWriter(w io.Writer, s Sampling) *SampleWriter {
+	return &SampleWriter{w: w, s: s}
+}
+
+// Write implements io.Writer
+func (s *SampleWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf = append(s.buf, p...)
+	for {
+		i := bytes.IndexAny(s.buf, "\r\n")
+		if i < 0 {
+			break
+		}
+		if s.buf[i] == '\r' && i+1 < len(s.buf) && s.buf[i+1] == '\n' {
+			i++
+		}
+		line := s.buf[:i+1]
+		s.buf = s.buf[i+1:]
+		if s.cr && i == 0 && line[0] == '\n' {
+			// the end of a "\r\n" split across writes, it belongs to the
+			// previous line
+			s.cr = false
+			if !s.kept {
+				continue
+			}
+		} else {
+			s.cr = line[i] == '\r'
+			if s.kept = s.keep(line); !s.kept {
+				continue
+			}
+		}
+		if _, err := s.w.Write(line); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+// keep reports whether line is part of the sample
+func (s *SampleWriter) keep(line []byte) bool {
+	if s.s.Keep != nil && s.s.Keep.Match(line) {
+		return true
+	}
+	s.n++
+	if s.s.Every > 1 && (s.n-1)%s.s.Every != 0 {
+		return false
+	}
+	if s.s.PerSecond > 0 {
+		now := time.Now()
+		if now.Sub(s.window) >= time.Second {
+			s.window, s.sent = now, 0
+		}
+		if s.sent >= s.s.PerSecond {
+			return false
+		}
+		s.sent++
+	}
+	return true
+}
+
+// Close writes the pending partial line, it doesn't close the underlying
+// writer
+func (s *SampleWriter) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.buf) == 0 {
+		return nil
+	}
+	line := s.buf
+	s.buf = nil
+	if !s.keep(line) {
+		return nil
+	}
+	_, err := s.w.Write(line)
+	return err
+}
@@ -0,0 +1,51 @@
+package ctxexec
+
+import (
+	"bytes"
+	"os/exec"
+	"regexp"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestSampleWriter_Every(t *testing.T) {
+	var out bytes.Buffer
+	s := NewSampleWriter(&out, Sampling{Every: 3, Keep: regexp.MustCompile("ERROR")})
+	cmd := exec.Command("bash", "-c", `for i in 1 2 3 4 5 6 7; do printf "$i%%\r"; done; echo ERROR`)
+	cmd.Stdout = s
+	c := New(cmd)
+	c.Start()
+	WaitAll(context.Background(), c)
+	s.Close()
+	if want := "1%\r4%\r7%\rERROR\n"; out.String() != want {
+		t.Fatalf("expected %q, got %q", want, out.String())
+	}
+}
+
+func TestSampleWriter_PerSecond(t *testing.T) {
+	var out bytes.Buffer
+	s := NewSampleWriter(&out, Sampling{PerSecond: 2})
+	s.Write([]byte("a\nb\nc\nd\n"))
+	s.Close()
+	if want := "a\nb\n"; out.String() != want {
+		t.Fatalf("expected %q, got %q", want, out.String())
+	}
+}
+
+func TestSampleWriter_CRLF(t *testing.T) {
+	for _, writes := range [][]string{
+		{"a\r\nb\r\nc\r\n"},
+		{"a\r", "\nb\r", "\nc\r", "\n"},
+	} {
+		var out bytes.Buffer
+		s := NewSampleWriter(&out, Sampling{Every: 2})
+		for _, w := range writes {
+			s.Write([]byte(w))
+		}
+		s.Close()
+		if want := "a\r\nc\r\n"; out.String() != want {
+			t.Fatalf("%q: expected %q, got %q", writes, want, out.String())
+		}
+	}
+}
@@ -0,0 +1,193 @@
+//go:build !windows
+// +build !windows
+
+package ctxexec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// shimEnv is the environment variable carrying the settings of the process
+// to the shim
+const shimEnv = "CTXEXEC_SHIM"
+
+// shimEnabled is set once ShimMain was called, the program can then be
+// started as the shim
+var shimEnabled bool
+
+// ShimMain makes the program the shim applying the Rlimits, Nice,
+// IOPriority, CPUAffinity and OOMScoreAdj of the commands between fork and
+// exec, by re-executing itself. It must be called first in main: when the program was started as
+// the shim, it applies the settings and executes the command without
+// returning. Commands with such settings fail to start unless it was called.
+//
+//	func main() {
+//		ctxexec.ShimMain()
+//		...
+//	}
+func ShimMain() {
+	if settings, ok := os.LookupEnv(shimEnv); ok {
+		os.Unsetenv(shimEnv)
+		if err := execShim(settings, os.Args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "ctxexec: shim: %v\n", err)
+			os.Exit(127)
+		}
+	}
+	shimEnabled = true
+}
+
+// execShim applies the settings, encoded by shimSettings, and executes the
+// program at args[0] with the arguments args[1:]
+func execShim(settings string, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("missing command")
+	}
+	// the priorities are per thread on linux, the thread applying them must
+	// be the one executing the command
+	runtime.LockOSThread()
+	for _, s := range strings.Split(settings, ",") {
+		var err error
+		switch kind, value, _ := strings.Cut(s, ":"); kind {
+		case "rlimit":
+			var r Rlimit
+			if _, err = fmt.Sscanf(value, "%d:%d:%d", &r.Resource, &r.Soft, &r.Hard); err == nil {
+				err = setrlimit(r)
+			}
+		case "nice":
+			var n int
+			if n, err = strconv.Atoi(value); err == nil {
+				err = syscall.Setpriority(syscall.PRIO_PROCESS, 0, n)
+			}
+		case "affinity":
+			var cpus []int
+			for _, f := range strings.Split(value, ";") {
+				var cpu int
+				if cpu, err = strconv.Atoi(f); err != nil {
+					break
+				}
+				cpus = append(cpus, cpu)
+			}
+			if err == nil {
+				err = setAffinity(cpus)
+			}
+		case "sync":
+			var fd int
+			if fd, err = strconv.Atoi(value); err == nil {
+				syscall.CloseOnExec(fd)
+			}
+		case "oom":
+			var score int
+			if score, err = strconv.Atoi(value); err == nil {
+				err = setOOMScoreAdj(score)
+			}
+		case "ioprio":
+			var p IOPriority
+			if _, err = fmt.Sscanf(value, "%d:%d", &p.Class, &p.Level); err == nil {
+				err = setIOPriority(p)
+			}
+		default:
+			err = fmt.Errorf("unknown setting")
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", s, err)
+		}
+	}
+	return syscall.Exec(args[0], args[1:], os.Environ())
+}
+
+// shimSettings returns the encoded settings of the command applied by the
+// shim, none if it doesn't need one
+func (c *CtxCmd) shimSettings() []string {
+	var settings []string
+	for _, r := range c.Rlimits {
+		settings = append(settings, fmt.Sprintf("rlimit:%d:%d:%d", r.Resource, r.Soft, r.Hard))
+	}
+	if c.Nice != 0 {
+		settings = append(settings, "nice:"+strconv.Itoa(c.Nice))
+	}
+	if c.IOPriority.Class != 0 {
+		settings = append(settings, fmt.Sprintf("ioprio:%d:%d", c.IOPriority.Class, c.IOPriority.Level))
+	}
+	if len(c.CPUAffinity) > 0 {
+		cpus := make([]string, len(c.CPUAffinity))
+		for i, cpu := range c.CPUAffinity {
+			cpus[i] = strconv.Itoa(cpu)
+		}
+		settings = append(settings, "affinity:"+strings.Join(cpus, ";"))
+	}
+	if c.OOMScoreAdj != 0 {
+		settings = append(settings, "oom:"+strconv.Itoa(c.OOMScoreAdj))
+	}
+	return settings
+}
+
+// shim has the process started by the shim, the program itself, when its
+// settings such as Rlimits need to be applied before the command is
+// executed. The command's Path, Args and Env are restored once it started.
+// An IOPriority, a CPUAffinity or an OOMScoreAdj is rejected up front on
+// platforms without them, or when invalid.
+func (c *CtxCmd) shim() error {
+	if c.IOPriority.Class != 0 && !ioPrioritySupported {
+		return errors.New("ctxexec: I/O priorities aren't supported on " + runtime.GOOS)
+	}
+	if len(c.CPUAffinity) > 0 {
+		if err := checkAffinity(c.CPUAffinity); err != nil {
+			return err
+		}
+	}
+	if c.OOMScoreAdj != 0 {
+		if err := checkOOMScoreAdj(c.OOMScoreAdj); err != nil {
+			return err
+		}
+	}
+	settings := c.shimSettings()
+	if len(settings) == 0 {
+		return nil
+	}
+	if !shimEnabled {
+		return errors.New("ctxexec: ShimMain must be called first in main to apply the settings of the command")
+	}
+	// the running program, even if its file was replaced since it started
+	self := "/proc/self/exe"
+	if runtime.GOOS != "linux" {
+		var err error
+		if self, err = os.Executable(); err != nil {
+			return fmt.Errorf("ctxexec: shim: %v", err)
+		}
+	}
+	// the shim closes the write end of the pipe on exec, the start returns
+	// once the settings are applied
+	r, w, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("ctxexec: shim: %v", err)
+	}
+	settings = append(settings, "sync:"+strconv.Itoa(3+len(c.ExtraFiles)))
+	path, args, env, files := c.Path, c.Args, c.Env, c.ExtraFiles
+	if env != nil {
+		env = append([]string(nil), env...)
+	}
+	c.Path, c.Args = self, append([]string{"ctxexec-shim", path}, args...)
+	c.ExtraFiles = append(files[:len(files):len(files)], w)
+	envSet(c.Cmd, shimEnv, strings.Join(settings, ","))
+	c.closeAfterStart = append(c.closeAfterStart, closerFunc(func() error {
+		w.Close()
+		io.Copy(ioutil.Discard, r)
+		r.Close()
+		c.Path, c.Args, c.Env, c.ExtraFiles = path, args, env, files
+		return nil
+	}))
+	return nil
+}
+
+// closerFunc is an io.Closer calling the function
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
@@ -0,0 +1,55 @@
+//go:build !windows
+// +build !windows
+
+package ctxexec
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestRlimit(t *testing.T) {
+	c := New(exec.Command("bash", "-c", "ulimit -Sn; ulimit -Hn", "shell"), WithRlimit(syscall.RLIMIT_NOFILE, 100, 200))
+	out, err := c.Output(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "100\n200\n" {
+		t.Fatalf("expected the limits 100 and 200, got %q", out)
+	}
+	if c.Path != "/bin/bash" && c.Path != "/usr/bin/bash" || c.Args[0] != "bash" {
+		t.Fatalf("expected the command to be restored, got %q %q", c.Path, c.Args)
+	}
+	var rl syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rl); err != nil || rl.Cur == 100 {
+		t.Fatalf("expected the limits of the parent to be left untouched, got %+v, %v", rl, err)
+	}
+}
+
+func TestRlimit_Invalid(t *testing.T) {
+	c := New(exec.Command("true"), WithRlimit(syscall.RLIMIT_NOFILE, 200, 100))
+	if err := c.Run(context.Background()); err == nil {
+		t.Fatal("expected a soft limit over the hard one to fail the command")
+	}
+}
+
+func TestNice(t *testing.T) {
+	out, err := New(exec.Command("nice"), WithNice(5)).Output(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "5\n" {
+		t.Fatalf("expected a niceness of 5, got %q", out)
+	}
+}
+
+func TestShimMain_NotCalled(t *testing.T) {
+	shimEnabled = false
+	defer func() { shimEnabled = true }()
+	if err := New(exec.Command("true"), WithNice(5)).Start(); err == nil {
+		t.Fatal("expected the command to fail to start without ShimMain")
+	}
+}
@@ -0,0 +1,19 @@
+//go:build windows
+// +build windows
+
+package ctxexec
+
+import "errors"
+
+// ShimMain does nothing on windows, where the settings applied by the shim
+// on unix aren't supported
+func ShimMain() {}
+
+// shim returns an error if the command has settings applied by the shim on
+// unix, such as Rlimits
+func (c *CtxCmd) shim() error {
+	if len(c.Rlimits) > 0 || c.Nice != 0 || c.IOPriority.Class != 0 || len(c.CPUAffinity) > 0 || c.OOMScoreAdj != 0 {
+		return errors.New("ctxexec: resource limits, priorities, cpu affinity and oom scores aren't supported on windows")
+	}
+	return nil
+}
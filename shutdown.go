@@ -0,0 +1,83 @@
+package ctxexec
+
+import (
+	"os"
+	ossignal "os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+var registry = struct {
+	sync.Mutex
+	cmds map[*CtxCmd]bool
+}{cmds: map[*CtxCmd]bool{}}
+
+// register adds the command to the shutdown registry until it exits
+func (c *CtxCmd) register() {
+	registry.Lock()
+	registry.cmds[c] = true
+	registry.Unlock()
+	c.onExit = append(c.onExit, func() {
+		registry.Lock()
+		delete(registry.cmds, c)
+		registry.Unlock()
+	})
+}
+
+// ShutdownAll stops all the running commands with RegisterShutdown
+// concurrently, as by Stop, and waits for them to exit. Each command is
+// given its Grace, bounded by the context's deadline, to exit before it is
+// killed. It returns the context's error if it is done first.
+func ShutdownAll(ctx context.Context) error {
+	registry.Lock()
+	cmds := make([]*CtxCmd, 0, len(registry.cmds))
+	for c := range registry.cmds {
+		cmds = append(cmds, c)
+	}
+	registry.Unlock()
+	var wg sync.WaitGroup
+	for _, c := range cmds {
+		wg.Add(1)
+		go func(c *CtxCmd) {
+			defer wg.Done()
+			sctx, cancel := context.WithTimeout(ctx, c.grace())
+			defer cancel()
+			c.Stop(sctx)
+			<-c.reap()
+		}(c)
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// ShutdownOnSignal calls ShutdownAll, bounded by grace, when the program
+// receives one of the signals, os.Interrupt and SIGTERM by default. The
+// signal is then delivered again with its default handling, so the program
+// still terminates. The returned function stops the handling.
+func ShutdownOnSignal(grace time.Duration, sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	ossignal.Notify(c, sigs...)
+	go func() {
+		select {
+		case sig := <-c:
+			ctx, cancel := context.WithTimeout(context.Background(), grace)
+			ShutdownAll(ctx)
+			cancel()
+			ossignal.Stop(c)
+			if p, err := os.FindProcess(os.Getpid()); err == nil {
+				p.Signal(sig)
+			}
+		case <-done:
+			ossignal.Stop(c)
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
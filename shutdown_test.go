@@ -0,0 +1,47 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestShutdownAll(t *testing.T) {
+	graceful := New(exec.Command("sleep", "5"))
+	stubborn := New(exec.Command("bash", "-c", `trap "" SIGINT SIGTERM; sleep 5`))
+	unregistered := New(exec.Command("sleep", "5"))
+	defer func() {
+		unregistered.Process.Kill()
+		unregistered.Cmd.Wait()
+	}()
+	for _, c := range []*CtxCmd{graceful, stubborn} {
+		c.RegisterShutdown = true
+		c.Grace = time.Millisecond * 200
+		if err := c.Start(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unregistered.Start()
+	time.Sleep(time.Millisecond * 100)
+
+	start := time.Now()
+	if err := ShutdownAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("expected the commands to be stopped within their grace, took %v", d)
+	}
+	if !graceful.stoppedGracefully() || stubborn.stoppedGracefully() {
+		t.Fatal("expected a graceful stop and a kill")
+	}
+	if unregistered.ProcessState != nil {
+		t.Fatal("expected the unregistered command to keep running")
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if len(registry.cmds) != 0 {
+		t.Fatalf("expected the registry to be empty, got %d commands", len(registry.cmds))
+	}
+}
@@ -0,0 +1,33 @@
+//go:build !windows
+// +build !windows
+
+package ctxexec
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/net/context"
+)
+
+// Socketpair attaches one end of a new unix stream socket pair to the
+// command through ExtraFiles and returns the other end, see PipeFrom.
+// The returned file can be turned into a net.Conn with net.FileConn.
+func (c *CtxCmd) Socketpair(ctx context.Context, env string) (*os.File, error) {
+	syscall.ForkLock.RLock()
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err == nil {
+		syscall.CloseOnExec(fds[0])
+		syscall.CloseOnExec(fds[1])
+	}
+	syscall.ForkLock.RUnlock()
+	if err != nil {
+		return nil, os.NewSyscallError("socketpair", err)
+	}
+	parent := os.NewFile(uintptr(fds[0]), "socketpair")
+	child := os.NewFile(uintptr(fds[1]), "socketpair")
+	if err := c.attach(ctx, env, child, parent); err != nil {
+		return nil, err
+	}
+	return parent, nil
+}
@@ -0,0 +1,34 @@
+//go:build !windows
+// +build !windows
+
+package ctxexec
+
+import (
+	"bufio"
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestSocketpair(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := New(exec.Command("bash", "-c", `read line <&$SOCK_FD; echo "got $line" >&$SOCK_FD; sleep 5`))
+	f, err := c.Socketpair(ctx, "SOCK_FD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Start()
+	defer c.Close()
+	f.Write([]byte("ping\n"))
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil || line != "got ping\n" {
+		t.Fatalf("unexpected reply %q, %v", line, err)
+	}
+	cancel()
+	time.Sleep(time.Millisecond * 100)
+	if _, err := f.Write([]byte("x")); err == nil {
+		t.Fatal("expected the parent's end to be closed with the context")
+	}
+}
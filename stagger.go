@@ -0,0 +1,37 @@
+package ctxexec
+
+import (
+	"math/rand"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// StartStaggered starts the commands one after the other, waiting delay
+// plus a random duration up to jitter between launches, so a large group
+// of workers doesn't hit shared resources all at once.
+//
+// It returns the number of commands started. When a command fails to
+// start or the context is done while waiting, the commands started so far
+// keep running and the error is returned.
+func StartStaggered(ctx context.Context, delay, jitter time.Duration, cmds ...*CtxCmd) (int, error) {
+	for i, c := range cmds {
+		if i > 0 {
+			wait := delay
+			if jitter > 0 {
+				wait += time.Duration(rand.Int63n(int64(jitter)))
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return i, ctx.Err()
+			}
+		}
+		if err := c.StartContext(ctx); err != nil {
+			return i, err
+		}
+	}
+	return len(cmds), nil
+}
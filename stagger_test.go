@@ -0,0 +1,30 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestStartStaggered(t *testing.T) {
+	cmds := []*CtxCmd{New(exec.Command("true")), New(exec.Command("true")), New(exec.Command("true"))}
+	start := time.Now()
+	n, err := StartStaggered(context.Background(), time.Millisecond*20, time.Millisecond*10, cmds...)
+	if err != nil || n != 3 {
+		t.Fatalf("expected 3 commands started, got %d, %v", n, err)
+	}
+	if d := time.Since(start); d < time.Millisecond*40 || d > time.Millisecond*500 {
+		t.Fatalf("expected the starts to be spaced by 20 to 30ms, took %v", d)
+	}
+	WaitAll(context.Background(), cmds...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*30)
+	defer cancel()
+	cmds = []*CtxCmd{New(exec.Command("true")), New(exec.Command("true"))}
+	if n, err := StartStaggered(ctx, time.Second, 0, cmds...); n != 1 || err != context.DeadlineExceeded {
+		t.Fatalf("expected 1 command started and %v, got %d, %v", context.DeadlineExceeded, n, err)
+	}
+	WaitAll(context.Background(), cmds[0])
+}
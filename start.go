@@ -0,0 +1,102 @@
+package ctxexec
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// ErrStartTimeout is returned when the command didn't start, or didn't become
+// ready, within its StartTimeout
+var ErrStartTimeout = errors.New("ctxexec: start timed out")
+
+// FirstOutput returns a Condition satisfied once the command writes to
+// Stdout or Stderr, it is meant to be used as the ReadyWhen condition
+// of the same command.
+//
+// Output is only observed when Stdout or Stderr is a writer other than
+// an *os.File.
+func (c *CtxCmd) FirstOutput() Condition {
+	return func(ctx context.Context) error {
+		select {
+		case <-c.timeline.outputChan():
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// exec starts the process, giving up after StartTimeout when set. The
+// process is started from a labelled goroutine, so the goroutines copying
+// its I/O inherit the labels.
+//
+// A process that starts after the timeout is killed and reaped in the background.
+func (c *CtxCmd) exec(deadline time.Time) error {
+	start := func() error {
+		err := c.Cmd.Start()
+		for _, f := range c.closeAfterStart {
+			f.Close()
+		}
+		if err != nil {
+			c.startFailed()
+		}
+		return err
+	}
+	errc := make(chan error, 1)
+	go func() {
+		c.labelGoroutine()
+		errc <- start()
+	}()
+	if deadline.IsZero() {
+		return <-errc
+	}
+	timer := time.NewTimer(deadline.Sub(time.Now()))
+	defer timer.Stop()
+	select {
+	case err := <-errc:
+		return err
+	case <-timer.C:
+		go func() {
+			if err := <-errc; err == nil {
+				kill(withCmd(context.Background(), c), c.Cmd)
+				c.Cmd.Wait()
+				c.startFailed()
+			}
+		}()
+		return ErrStartTimeout
+	}
+}
+
+// awaitReady waits for the ReadyWhen condition, bounded by the start deadline
+// when set. The process is killed and reaped when it doesn't become ready.
+func (c *CtxCmd) awaitReady(ctx context.Context, deadline time.Time) error {
+	if c.ReadyWhen == nil {
+		return nil
+	}
+	rctx := ctx
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		rctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+	err := c.ReadyWhen(rctx)
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() == nil && rctx.Err() == context.DeadlineExceeded {
+		err = ErrStartTimeout
+	}
+	kill(withCmd(ctx, c), c.Cmd)
+	<-c.reap()
+	return err
+}
+
+// startFailed calls the onStartFailure functions, once the process failed
+// to start and its output is no longer written
+func (c *CtxCmd) startFailed() {
+	for _, f := range c.onStartFailure {
+		f()
+	}
+}
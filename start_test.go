@@ -0,0 +1,105 @@
+package ctxexec
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestStartTimeout_Ready(t *testing.T) {
+	var out bytes.Buffer
+	cmd := exec.Command("bash", "-c", `sleep 0.2; echo ready; sleep 0.2`)
+	cmd.Stdout = &out
+	c := New(cmd)
+	c.StartTimeout = time.Second
+	c.ReadyWhen = c.FirstOutput()
+	if err := c.Start(); err != nil {
+		t.Fatalf("expected start, got %v", err)
+	}
+	c.Cmd.Wait()
+}
+
+func TestStartTimeout_NotReady(t *testing.T) {
+	var out bytes.Buffer
+	cmd := exec.Command("sleep", "5")
+	cmd.Stdout = &out
+	c := New(cmd)
+	c.StartTimeout = time.Millisecond * 200
+	c.ReadyWhen = c.FirstOutput()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	start := time.Now()
+	if err := c.Run(ctx); err != ErrStartTimeout {
+		t.Fatalf("expected ErrStartTimeout, got %v", err)
+	}
+	if time.Since(start) > time.Second*2 {
+		t.Fatal("expected to fail fast")
+	}
+	if !c.stopped() {
+		t.Fatal("expected the process to be stopped")
+	}
+}
+
+func TestWithStartTimeout(t *testing.T) {
+	c := New(exec.Command("sleep", "5"),
+		WithStartTimeout(time.Millisecond*200),
+		WithReadyWhen(FileExists("/nonexistent/ready")),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	start := time.Now()
+	if err := c.StartContext(ctx); err != ErrStartTimeout {
+		t.Fatalf("expected ErrStartTimeout, got %v", err)
+	}
+	if time.Since(start) > time.Second*2 {
+		t.Fatal("expected to fail fast, independently of the run context")
+	}
+}
+
+// countingLock counts the releases of its lock
+type countingLock struct {
+	mu       sync.Mutex
+	unlocked int
+}
+
+func (l *countingLock) Lock(ctx context.Context, name string) (func(), error) {
+	return func() {
+		l.mu.Lock()
+		l.unlocked++
+		l.mu.Unlock()
+	}, nil
+}
+
+func TestWithStartTimeout_ReleasedOnce(t *testing.T) {
+	lock := &countingLock{}
+	c := New(exec.Command("sleep", "5"),
+		WithStartTimeout(time.Millisecond*100),
+		WithReadyWhen(FileExists("/nonexistent/ready")),
+	)
+	c.Lock = lock
+	if err := c.Start(); err != ErrStartTimeout {
+		t.Fatalf("expected ErrStartTimeout, got %v", err)
+	}
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+	if lock.unlocked != 1 {
+		t.Fatalf("expected the lock to be released once, released %d times", lock.unlocked)
+	}
+}
+
+func TestStartTimeout_NotReadyGroup(t *testing.T) {
+	c, grandchild := groupCommand(t)
+	c.StartTimeout = time.Millisecond * 500
+	c.ReadyWhen = func(context.Context) error { grandchild(); return errors.New("not ready") }
+	if err := c.Start(); err == nil {
+		t.Fatal("expected the command not to become ready")
+	}
+	if !exitedPid(grandchild()) {
+		t.Fatal("expected the process group to be killed")
+	}
+}
@@ -0,0 +1,110 @@
+package ctxexec
+
+import "errors"
+
+var (
+	// ErrAlreadyStarted is returned when starting a command that is running
+	ErrAlreadyStarted = errors.New("ctxexec: command already started")
+	// ErrAlreadyWaited is returned when waiting again for a command
+	ErrAlreadyWaited = errors.New("ctxexec: command already waited for")
+	// ErrSpent is returned when starting a command that already ran, a
+	// command runs once, use a Builder or a function returning new
+	// commands to run the same one again
+	ErrSpent = errors.New("ctxexec: command already ran")
+	// ErrNotRunning is returned when signalling a command that isn't running
+	ErrNotRunning = errors.New("ctxexec: command not running")
+)
+
+// Status is the lifecycle state of a command
+type Status int
+
+const (
+	// StatusCreated is the state of a command that wasn't started
+	StatusCreated Status = iota
+	// StatusStarting is the state of a command being started, while its
+	// conditions, hooks and reservations are awaited
+	StatusStarting
+	// StatusRunning is the state of a command whose process is running
+	StatusRunning
+	// StatusStopping is the state of a running command being stopped
+	StatusStopping
+	// StatusExited is the state of a command whose process exited
+	// successfully
+	StatusExited
+	// StatusFailed is the state of a command that failed to start or whose
+	// process exited with an error
+	StatusFailed
+)
+
+var statusNames = map[Status]string{
+	StatusCreated:  "created",
+	StatusStarting: "starting",
+	StatusRunning:  "running",
+	StatusStopping: "stopping",
+	StatusExited:   "exited",
+	StatusFailed:   "failed",
+}
+
+// String returns the name of the status
+func (s Status) String() string {
+	if n, ok := statusNames[s]; ok {
+		return n
+	}
+	return "unknown"
+}
+
+// Status returns the lifecycle state of the command. The exit of the
+// process is tracked once it is reaped, by Wait, Done or in the background.
+func (c *CtxCmd) Status() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.status
+}
+
+// setStatus sets the status of the command
+func (c *CtxCmd) setStatus(s Status) {
+	c.mu.Lock()
+	c.status = s
+	c.mu.Unlock()
+}
+
+// transition sets the status of the command to to if it is from
+func (c *CtxCmd) transition(from, to Status) {
+	c.mu.Lock()
+	if c.status == from {
+		c.status = to
+	}
+	c.mu.Unlock()
+}
+
+// checkStart returns an error if the command was already started
+func (c *CtxCmd) checkStart() error {
+	if c.Process == nil {
+		return nil
+	}
+	c.mu.Lock()
+	reaping, reaped := c.reaping, c.reaped
+	c.mu.Unlock()
+	// the process state is only read when it isn't set in the background
+	if reaped || (!reaping && c.ProcessState != nil) {
+		return ErrSpent
+	}
+	return ErrAlreadyStarted
+}
+
+// checkWait returns ErrAlreadyWaited if Wait already returned
+func (c *CtxCmd) checkWait() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.waited {
+		return ErrAlreadyWaited
+	}
+	return nil
+}
+
+// setWaited records Wait returned
+func (c *CtxCmd) setWaited() {
+	c.mu.Lock()
+	c.waited = true
+	c.mu.Unlock()
+}
@@ -0,0 +1,110 @@
+package ctxexec
+
+import (
+	"bytes"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestMisuse(t *testing.T) {
+	c := New(exec.Command("sleep", "0.1"))
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Start(); err != ErrAlreadyStarted {
+		t.Fatalf("expected %v, got %v", ErrAlreadyStarted, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	WaitAll(ctx, c)
+	if err := c.Start(); err != ErrSpent {
+		t.Fatalf("expected %v, got %v", ErrSpent, err)
+	}
+	cctx, ccancel := context.WithCancel(context.Background())
+	ccancel()
+	c.Wait(cctx)
+	if err := c.Wait(ctx); err != ErrAlreadyWaited {
+		t.Fatalf("expected %v, got %v", ErrAlreadyWaited, err)
+	}
+
+	c = New(exec.Command("true"))
+	c.Start()
+	c.Cmd.Wait()
+	if err := c.Start(); err != ErrSpent {
+		t.Fatalf("expected %v after Cmd.Wait, got %v", ErrSpent, err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	c := New(exec.Command("bash", "-c", "sleep 5"))
+	if c.Status() != StatusCreated {
+		t.Fatalf("expected created, got %v", c.Status())
+	}
+	c.Start()
+	if c.Status() != StatusRunning {
+		t.Fatalf("expected running, got %v", c.Status())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	c.Wait(ctx)
+	if c.Status() != StatusFailed {
+		t.Fatalf("expected a stopped command to have failed, got %v", c.Status())
+	}
+
+	c = New(exec.Command("true"))
+	c.Run(context.Background())
+	if c.Status() != StatusExited {
+		t.Fatalf("expected exited, got %v", c.Status())
+	}
+
+	c = New(exec.Command("/nonexistent"))
+	c.Start()
+	if c.Status() != StatusFailed {
+		t.Fatalf("expected a start failure, got %v", c.Status())
+	}
+}
+
+func TestStatus_Stopping(t *testing.T) {
+	c := New(exec.Command("bash", "-c", `trap "sleep 0.3; exit 0" SIGINT SIGTERM; while true; do sleep 0.1; done`))
+	c.Start()
+	time.Sleep(time.Millisecond * 100) // let bash install the traps
+	go c.Close()
+	time.Sleep(time.Millisecond * 100)
+	if c.Status() != StatusStopping {
+		t.Fatalf("expected stopping, got %v", c.Status())
+	}
+	<-c.Done()
+	if c.Status() != StatusExited {
+		t.Fatalf("expected exited, got %v", c.Status())
+	}
+}
+
+func TestSignal(t *testing.T) {
+	c := New(exec.Command("bash", "-c", `trap "echo reloaded" SIGHUP; sleep 0.3; exit 0`))
+	if err := c.Signal(syscall.SIGHUP); err != ErrNotRunning {
+		t.Fatalf("expected %v before the start, got %v", ErrNotRunning, err)
+	}
+	var out bytes.Buffer
+	c.Stdout = &out
+	c.Start()
+	time.Sleep(time.Millisecond * 100) // let bash install the trap
+	if err := c.Signal(syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	c.Wait(context.Background())
+	if out.String() != "reloaded\n" {
+		t.Fatalf("expected the signal to be delivered, got %q", out.String())
+	}
+	if err := c.Signal(syscall.SIGHUP); err != ErrNotRunning {
+		t.Fatalf("expected %v after the exit, got %v", ErrNotRunning, err)
+	}
+	for _, e := range c.Timeline() {
+		if e.Kind == EventSignal {
+			t.Fatal("expected the signal not to be recorded")
+		}
+	}
+}
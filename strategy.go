@@ -0,0 +1,97 @@
+package ctxexec
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// StopStrategy returns a StopFunc configured by arg, the part of a
+// strategy spec following the colon
+type StopStrategy func(arg string) (StopFunc, error)
+
+var (
+	strategiesMu sync.RWMutex
+	strategies   = map[string]StopStrategy{
+		"default":  func(string) (StopFunc, error) { return stopFunc, nil },
+		"signal":   signalStrategy,
+		"escalate": escalateStrategy,
+		"command":  func(arg string) (StopFunc, error) { return StopCommand(strings.Fields(arg)...), nil },
+	}
+)
+
+// RegisterStopStrategy registers a named StopStrategy, so declarative
+// configurations can reference it with LookupStopStrategy
+func RegisterStopStrategy(name string, s StopStrategy) {
+	strategiesMu.Lock()
+	strategies[name] = s
+	strategiesMu.Unlock()
+}
+
+// LookupStopStrategy returns the StopFunc described by spec, in the
+// name:arg form. The built-in strategies are:
+//
+//	default                  the default StopFunc
+//	signal:TERM,10s          send the signals in order, kill after the optional duration
+//	escalate:INT,2s,TERM,5s  send each signal then wait the optional duration, see Escalate
+//	command:-s quit          run the command's binary with the arguments, see StopCommand
+func LookupStopStrategy(spec string) (StopFunc, error) {
+	name, arg := spec, ""
+	if i := strings.IndexByte(spec, ':'); i >= 0 {
+		name, arg = spec[:i], spec[i+1:]
+	}
+	strategiesMu.RLock()
+	s, ok := strategies[name]
+	strategiesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("ctxexec: unknown stop strategy %q", name)
+	}
+	return s(arg)
+}
+
+// parseSignal returns the signal named name, with or without the SIG prefix
+func parseSignal(name string) (os.Signal, bool) {
+	sig, ok := signalsByName[strings.TrimPrefix(strings.ToUpper(name), "SIG")]
+	return sig, ok
+}
+
+// signalStrategy parses comma separated signal names followed by an
+// optional duration to wait before the kill
+func signalStrategy(arg string) (StopFunc, error) {
+	var sigs []os.Signal
+	var wait time.Duration
+	for _, f := range strings.Split(arg, ",") {
+		f = strings.TrimSpace(f)
+		if d, err := time.ParseDuration(f); err == nil {
+			wait = d
+			continue
+		}
+		sig, ok := parseSignal(f)
+		if !ok {
+			return nil, fmt.Errorf("ctxexec: unknown signal %q", f)
+		}
+		sigs = append(sigs, sig)
+	}
+	if len(sigs) == 0 {
+		return nil, fmt.Errorf("ctxexec: no signal in %q", arg)
+	}
+	return func(ctx context.Context, cmd *exec.Cmd) error {
+		if cmd == nil || cmd.Process == nil {
+			return nil
+		}
+		for _, sig := range sigs {
+			signal(ctx, cmd, sig)
+		}
+		if wait > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, wait)
+			defer cancel()
+		}
+		return awaitExit(ctx, cmd)
+	}, nil
+}
@@ -0,0 +1,51 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestLookupStopStrategy(t *testing.T) {
+	for _, spec := range []string{"unknown", "signal:", "signal:BOGUS,1s"} {
+		if _, err := LookupStopStrategy(spec); err == nil {
+			t.Fatalf("expected %q to be rejected", spec)
+		}
+	}
+
+	stop, err := LookupStopStrategy("signal:HUP,200ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New(exec.Command("bash", "-c", `trap "" SIGINT SIGTERM SIGHUP; sleep 5`))
+	c.StopFunc = stop
+	c.Start()
+	time.Sleep(time.Millisecond * 100) // let bash install the traps
+	start := time.Now()
+	if err := c.Stop(context.Background()); err != context.DeadlineExceeded {
+		t.Fatalf("expected a kill after 200ms, got %v", err)
+	}
+	if time.Since(start) > time.Second*2 {
+		t.Fatal("expected the strategy duration to bound the stop")
+	}
+}
+
+func TestRegisterStopStrategy(t *testing.T) {
+	called := false
+	RegisterStopStrategy("test", func(arg string) (StopFunc, error) {
+		return func(ctx context.Context, cmd *exec.Cmd) error {
+			called = arg == "x"
+			return nil
+		}, nil
+	})
+	stop, err := LookupStopStrategy("test:x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop(context.Background(), nil)
+	if !called {
+		t.Fatal("expected the registered strategy")
+	}
+}
@@ -0,0 +1,165 @@
+package ctxexec
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// RestartPolicy tells a Supervisor when to restart its command
+type RestartPolicy int
+
+const (
+	// RestartAlways restarts the command whenever it exits
+	RestartAlways RestartPolicy = iota
+	// RestartOnFailure restarts the command when it fails
+	RestartOnFailure
+	// RestartNever runs the command once
+	RestartNever
+)
+
+// Supervisor keeps a long running command running, restarting it
+// according to its RestartPolicy with an exponential backoff, so a command
+// crashing in a loop doesn't spin
+type Supervisor struct {
+	// Command returns a new command for each run, a command runs once
+	Command func() *CtxCmd
+
+	// Restart is the RestartPolicy, RestartAlways by default
+	Restart RestartPolicy
+
+	// MaxRestarts is the number of restarts after which the supervisor
+	// gives up, zero means no limit
+	MaxRestarts int
+
+	// MinBackoff is the delay before the first restart, one second when
+	// zero. It doubles after each run shorter than MaxBackoff, up to
+	// MaxBackoff, one minute when zero, and is reset by longer runs.
+	MinBackoff, MaxBackoff time.Duration
+
+	mu       sync.Mutex
+	cmd      *CtxCmd
+	restarts int
+}
+
+// Run runs the command, restarting it until the RestartPolicy or
+// MaxRestarts tell otherwise, and returns the error of the last run. Once
+// the context is done, the running command is stopped as by its Wait and
+// Run returns the context's error.
+func (s *Supervisor) Run(ctx context.Context) error {
+	return s.run(ctx, nil)
+}
+
+// Resume is Run for a supervisor restarted while its command kept running,
+// as recorded by Record. It adopts the recorded process, see Adopt, rather
+// than starting the command twice, waits for it then carries on as Run,
+// counting the restarts from the record's.
+//
+// The exit status of an adopted process is unknown, it is restarted
+// unless the RestartPolicy is RestartNever. A recorded process that exited
+// meanwhile is restarted right away, Resume returns ErrProcessGone instead
+// with RestartNever.
+func (s *Supervisor) Resume(ctx context.Context, r ProcessRecord) error {
+	s.mu.Lock()
+	s.restarts = r.Restarts
+	s.mu.Unlock()
+	c, err := Adopt(r)
+	if err == ErrProcessGone && s.Restart != RestartNever {
+		logf(ctx, Errors, "%q: %v, restarting", r.Args, err)
+		s.mu.Lock()
+		s.restarts++
+		s.mu.Unlock()
+		return s.run(ctx, nil)
+	}
+	if err != nil {
+		return err
+	}
+	return s.run(ctx, c)
+}
+
+// run runs the command, after waiting for the adopted one if not nil
+func (s *Supervisor) run(ctx context.Context, adopted *CtxCmd) error {
+	min, max := s.MinBackoff, s.MaxBackoff
+	if min <= 0 {
+		min = time.Second
+	}
+	if max <= 0 {
+		max = time.Minute
+	}
+	backoff := min
+	for {
+		c := adopted
+		if c == nil {
+			c = s.Command()
+		}
+		s.mu.Lock()
+		s.cmd = c
+		restarts := s.restarts
+		s.mu.Unlock()
+		start := time.Now()
+		var err error
+		if adopted != nil {
+			err = adopted.Wait(ctx)
+			adopted = nil
+		} else {
+			err = c.Run(ctx)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if s.Restart == RestartNever || (s.Restart == RestartOnFailure && err == nil && !c.adopted) {
+			return err
+		}
+		if s.MaxRestarts > 0 && restarts >= s.MaxRestarts {
+			logf(withCmd(ctx, c), Errors, "%q: %v, giving up after %d restarts", c.Args, err, restarts)
+			return err
+		}
+		if time.Since(start) >= max {
+			backoff = min
+		}
+		logf(withCmd(ctx, c), Errors, "%q: exited: %v, restarting in %v", c.Args, err, backoff)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		if backoff *= 2; backoff > max {
+			backoff = max
+		}
+		s.mu.Lock()
+		s.restarts++
+		s.mu.Unlock()
+	}
+}
+
+// Cmd returns the command of the current or last run, nil before the
+// first one
+func (s *Supervisor) Cmd() *CtxCmd {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cmd
+}
+
+// Restarts returns the number of restarts so far
+func (s *Supervisor) Restarts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.restarts
+}
+
+// Record returns the ProcessRecord of the current command, with the
+// number of restarts
+func (s *Supervisor) Record() ProcessRecord {
+	s.mu.Lock()
+	c, restarts := s.cmd, s.restarts
+	s.mu.Unlock()
+	if c == nil {
+		return ProcessRecord{Pid: -1, Restarts: restarts}
+	}
+	r := c.Record()
+	r.Restarts = restarts
+	return r
+}
@@ -0,0 +1,97 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestSupervisor(t *testing.T) {
+	runs := 0
+	s := &Supervisor{
+		Command: func() *CtxCmd {
+			runs++
+			return New(exec.Command("bash", "-c", "exit 1"))
+		},
+		Restart:     RestartOnFailure,
+		MaxRestarts: 3,
+		MinBackoff:  time.Millisecond * 20,
+		MaxBackoff:  time.Millisecond * 50,
+	}
+	start := time.Now()
+	if err := s.Run(context.Background()); err == nil {
+		t.Fatal("expected the error of the last run")
+	}
+	if runs != 4 || s.Restarts() != 3 || s.Record().Restarts != 3 {
+		t.Fatalf("expected 4 runs and 3 restarts, got %d and %d", runs, s.Restarts())
+	}
+	// backs off 20, 40 then 50ms
+	if d := time.Since(start); d < time.Millisecond*110 {
+		t.Fatalf("expected an exponential backoff, took %v", d)
+	}
+
+	runs = 0
+	s = &Supervisor{Command: func() *CtxCmd { runs++; return New(exec.Command("true")) }, Restart: RestartOnFailure}
+	if err := s.Run(context.Background()); err != nil || runs != 1 {
+		t.Fatalf("expected a successful run not to be restarted, got %d runs, %v", runs, err)
+	}
+}
+
+func TestSupervisor_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Supervisor{
+		Command:    func() *CtxCmd { return New(exec.Command("sleep", "5")) },
+		MinBackoff: time.Millisecond * 10,
+	}
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+	time.Sleep(time.Millisecond * 100)
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Fatalf("expected %v, got %v", context.Canceled, err)
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatal("expected the supervisor to stop with the context")
+	}
+	if code, _ := s.Cmd().ExitCode(); code != -1 || s.Cmd().Status() != StatusFailed {
+		t.Fatalf("expected the command to be stopped, got %v", s.Cmd().Status())
+	}
+}
+
+func TestSupervisor_Resume(t *testing.T) {
+	// the previous supervisor started the command and recorded it
+	c := New(exec.Command("sleep", "0.3"))
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	go c.Cmd.Wait() // the previous supervisor reaps its child
+	r := c.Record()
+	r.Restarts = 2
+
+	runs := 0
+	s := &Supervisor{
+		Command:     func() *CtxCmd { runs++; return New(exec.Command("true")) },
+		MaxRestarts: 3,
+		MinBackoff:  time.Millisecond * 10,
+	}
+	start := time.Now()
+	if err := s.Resume(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < time.Millisecond*200 {
+		t.Fatalf("expected the adopted process waited for, took %v", d)
+	}
+	if runs != 1 || s.Restarts() != 3 {
+		t.Fatalf("expected 1 run and the restarts counted from the record, got %d and %d", runs, s.Restarts())
+	}
+
+	runs = 0
+	s = &Supervisor{Command: func() *CtxCmd { runs++; return New(exec.Command("true")) }, Restart: RestartOnFailure}
+	if err := s.Resume(context.Background(), r); err != nil || runs != 1 || s.Restarts() != 3 {
+		t.Fatalf("expected the exited process restarted, got %d runs, %d restarts, %v", runs, s.Restarts(), err)
+	}
+}
@@ -0,0 +1,92 @@
+//go:build !windows
+// +build !windows
+
+package ctxexec
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/net/context"
+)
+
+// sysProc is the platform state of a started process, none on unix
+type sysProc struct{}
+
+var signalsByName = map[string]os.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+	"TERM": syscall.SIGTERM,
+}
+
+// prepareProcess sets the platform attributes of the command before it is
+// executed
+func (c *CtxCmd) prepareProcess() {
+	if c.ProcessGroup {
+		setProcessGroup(c.Cmd)
+	}
+	if c.DieWithParent {
+		c.setDieWithParent()
+	}
+}
+
+// attachProcess sets up the platform state of the started process
+func (c *CtxCmd) attachProcess(ctx context.Context) {
+	if c.DieWithParent {
+		c.watchParent(ctx)
+	}
+}
+
+// detachProcess releases the platform state of the exited process
+func (c *CtxCmd) detachProcess() {}
+
+// setProcessGroup makes the command's process the leader of a new process
+// group, which its descendants join
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// signalGroup sends the signal to every process of the group led by pid
+func signalGroup(pid int, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return os.ErrInvalid
+	}
+	return syscall.Kill(-pid, s)
+}
+
+// inGroup returns true if the command is carried by the context and leads
+// its own process group
+func inGroup(ctx context.Context, cmd *exec.Cmd) bool {
+	c, ok := ctx.Value(cmdKey{}).(*CtxCmd)
+	return ok && c.Cmd == cmd && c.ProcessGroup
+}
+
+// sendSignal sends the signal to the process, or to its group
+func sendSignal(ctx context.Context, cmd *exec.Cmd, sig os.Signal) error {
+	if inGroup(ctx, cmd) {
+		return signalGroup(cmd.Process.Pid, sig)
+	}
+	return cmd.Process.Signal(sig)
+}
+
+// killProcess kills the process, or its group
+func killProcess(ctx context.Context, cmd *exec.Cmd) error {
+	if inGroup(ctx, cmd) {
+		return signalGroup(cmd.Process.Pid, os.Kill)
+	}
+	return cmd.Process.Kill()
+}
+
+// alive returns true if the process is still running
+func alive(p *os.Process) bool {
+	return p.Signal(syscall.Signal(0)) == nil
+}
@@ -0,0 +1,53 @@
+//go:build !windows
+// +build !windows
+
+package ctxexec
+
+import (
+	"bufio"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestProcessGroup(t *testing.T) {
+	c := New(exec.Command("bash", "-c", `sleep 30 & echo $!; wait`), WithProcessGroup())
+	out, err := c.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Start()
+	line, err := bufio.NewReader(out).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+	c.Stop(ctx)
+	<-c.reap()
+	for deadline := time.Now().Add(time.Second * 2); running(pid); {
+		if time.Now().After(deadline) {
+			syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatal("expected the grandchild to be stopped with the group")
+		}
+		time.Sleep(time.Millisecond * 50)
+	}
+}
+
+// running returns true if the process exists and isn't a zombie
+func running(pid int) bool {
+	if syscall.Kill(pid, 0) != nil {
+		return false
+	}
+	stat, err := exec.Command("ps", "-o", "stat=", "-p", strconv.Itoa(pid)).Output()
+	return err == nil && !strings.HasPrefix(strings.TrimSpace(string(stat)), "Z")
+}
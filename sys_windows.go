@@ -0,0 +1,165 @@
+//go:build windows
+// +build windows
+
+package ctxexec
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/net/context"
+)
+
+// sysProc is the platform state of a started process, the job object its
+// descendants are assigned to
+type sysProc struct {
+	job syscall.Handle
+}
+
+// signalsByName are the signals understood on Windows, KILL terminates the
+// process and the others are delivered as a console CTRL_BREAK_EVENT
+var signalsByName = map[string]os.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+}
+
+const (
+	processSetQuota                = 0x0100
+	jobObjectExtendedLimitInfo     = 9
+	jobObjectLimitKillOnJobClose   = 0x2000
+	processQueryLimitedInformation = 0x1000
+	stillActive                    = 259
+)
+
+var (
+	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
+	procCreateJobObject          = kernel32.NewProc("CreateJobObjectW")
+	procAssignProcessToJobObject = kernel32.NewProc("AssignProcessToJobObject")
+	procTerminateJobObject       = kernel32.NewProc("TerminateJobObject")
+	procSetInformationJobObject  = kernel32.NewProc("SetInformationJobObject")
+	procGenerateConsoleCtrlEvent = kernel32.NewProc("GenerateConsoleCtrlEvent")
+)
+
+// jobExtendedLimitInformation is the JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+// structure
+type jobExtendedLimitInformation struct {
+	BasicLimitInformation struct {
+		PerProcessUserTimeLimit int64
+		PerJobUserTimeLimit     int64
+		LimitFlags              uint32
+		MinimumWorkingSetSize   uintptr
+		MaximumWorkingSetSize   uintptr
+		ActiveProcessLimit      uint32
+		Affinity                uintptr
+		PriorityClass           uint32
+		SchedulingClass         uint32
+	}
+	IoInfo                struct{ ReadOperationCount, WriteOperationCount, OtherOperationCount, ReadTransferCount, WriteTransferCount, OtherTransferCount uint64 }
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+// prepareProcess starts the command in a new console process group, so
+// console control events can be sent to it alone
+func (c *CtxCmd) prepareProcess() {
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	c.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// attachProcess assigns the started process to a new job object, which the
+// processes it creates join, so they are terminated along with it. The
+// processes created before the assignment aren't part of the job.
+func (c *CtxCmd) attachProcess(ctx context.Context) {
+	ctx = withCmd(ctx, c)
+	job, _, err := procCreateJobObject.Call(0, 0)
+	if job == 0 {
+		logf(ctx, Errors, "pid %d: create job object: %v", c.Process.Pid, err)
+		return
+	}
+	if c.DieWithParent {
+		var info jobExtendedLimitInformation
+		info.BasicLimitInformation.LimitFlags = jobObjectLimitKillOnJobClose
+		if ok, _, err := procSetInformationJobObject.Call(job, jobObjectExtendedLimitInfo, uintptr(unsafe.Pointer(&info)), unsafe.Sizeof(info)); ok == 0 {
+			logf(ctx, Errors, "pid %d: set job object limits: %v", c.Process.Pid, err)
+		}
+	}
+	h, err := syscall.OpenProcess(processSetQuota|syscall.PROCESS_TERMINATE, false, uint32(c.Process.Pid))
+	if err != nil {
+		syscall.CloseHandle(syscall.Handle(job))
+		logf(ctx, Errors, "pid %d: open process: %v", c.Process.Pid, err)
+		return
+	}
+	defer syscall.CloseHandle(h)
+	if ok, _, err := procAssignProcessToJobObject.Call(job, uintptr(h)); ok == 0 {
+		syscall.CloseHandle(syscall.Handle(job))
+		logf(ctx, Errors, "pid %d: assign job object: %v", c.Process.Pid, err)
+		return
+	}
+	c.mu.Lock()
+	c.sys.job = syscall.Handle(job)
+	c.mu.Unlock()
+}
+
+// detachProcess closes the job object of the exited process
+func (c *CtxCmd) detachProcess() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sys.job != 0 {
+		syscall.CloseHandle(c.sys.job)
+		c.sys.job = 0
+	}
+}
+
+// sendSignal delivers os.Kill by terminating the process, the other signals
+// as a CTRL_BREAK_EVENT to its console process group, which Go programs
+// receive as os.Interrupt
+func sendSignal(ctx context.Context, cmd *exec.Cmd, sig os.Signal) error {
+	if sig == os.Kill {
+		return killProcess(ctx, cmd)
+	}
+	ok, _, err := procGenerateConsoleCtrlEvent.Call(syscall.CTRL_BREAK_EVENT, uintptr(cmd.Process.Pid))
+	if ok == 0 {
+		return err
+	}
+	return nil
+}
+
+// killProcess terminates the job object of the process, killing its
+// descendants, or the process alone when it has none
+func killProcess(ctx context.Context, cmd *exec.Cmd) error {
+	if c, ok := ctx.Value(cmdKey{}).(*CtxCmd); ok && c.Cmd == cmd {
+		c.mu.Lock()
+		job := c.sys.job
+		c.mu.Unlock()
+		if job != 0 {
+			if ok, _, err := procTerminateJobObject.Call(uintptr(job), 1); ok == 0 {
+				return err
+			}
+			return nil
+		}
+	}
+	return cmd.Process.Kill()
+}
+
+// alive returns true if the process is still running
+func alive(p *os.Process) bool {
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(p.Pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
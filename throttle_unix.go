@@ -0,0 +1,112 @@
+//go:build !windows
+// +build !windows
+
+package ctxexec
+
+import (
+	"errors"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// ThrottlePeriod is the period of the stop and continue cycle of a Throttler
+var ThrottlePeriod = time.Millisecond * 100
+
+// Throttler caps the CPU share of a running process by alternately
+// suspending it with SIGSTOP and resuming it with SIGCONT, such as to
+// deprioritize a backup job while the host is busy. With ProcessGroup the
+// whole group is suspended and resumed.
+//
+// Throttling ends, leaving the process running, once it is stopped: when
+// a signal is sent to stop it the throttler resumes it within a period so
+// it can handle the signal.
+type Throttler struct {
+	c     *CtxCmd
+	mu    sync.Mutex
+	share float64
+	once  sync.Once
+	done  chan struct{}
+}
+
+// Throttle starts throttling the running process to the share of CPU time,
+// between 0.01 and 1, see SetShare
+func (c *CtxCmd) Throttle(share float64) (*Throttler, error) {
+	if c.Process == nil {
+		return nil, errors.New("ctxexec: throttling a command that isn't started")
+	}
+	t := &Throttler{c: c, done: make(chan struct{})}
+	t.SetShare(share)
+	go t.run()
+	return t, nil
+}
+
+// SetShare adjusts the share of CPU time of the process, it applies from
+// the next period. Shares are clamped to 0.01, a share of 1 or more lets the
+// process run unthrottled.
+func (t *Throttler) SetShare(share float64) {
+	if share < 0.01 {
+		share = 0.01
+	}
+	t.mu.Lock()
+	t.share = share
+	t.mu.Unlock()
+}
+
+// Share returns the share of CPU time of the process
+func (t *Throttler) Share() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.share
+}
+
+// Stop ends throttling, leaving the process running
+func (t *Throttler) Stop() {
+	t.once.Do(func() { close(t.done) })
+}
+
+func (t *Throttler) run() {
+	exited := t.c.reap()
+	// the whole group is suspended with ProcessGroup, not only the process
+	ctx := withCmd(context.Background(), t.c)
+	for {
+		share := t.Share()
+		on := ThrottlePeriod
+		if share < 1 {
+			on = time.Duration(float64(ThrottlePeriod) * share)
+		}
+		if !t.sleep(on, exited) {
+			return
+		}
+		if share >= 1 {
+			continue
+		}
+		sendSignal(ctx, t.c.Cmd, syscall.SIGSTOP)
+		ok := t.sleep(ThrottlePeriod-on, exited)
+		sendSignal(ctx, t.c.Cmd, syscall.SIGCONT)
+		if !ok {
+			return
+		}
+	}
+}
+
+// sleep sleeps for d, it returns false if throttling should end
+func (t *Throttler) sleep(d time.Duration, exited <-chan struct{}) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-exited:
+		return false
+	case <-t.done:
+		return false
+	}
+	for _, e := range t.c.Timeline() {
+		if e.Kind == EventSignal || e.Kind == EventKill {
+			return false
+		}
+	}
+	return true
+}
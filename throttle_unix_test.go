@@ -0,0 +1,71 @@
+//go:build !windows
+// +build !windows
+
+package ctxexec
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestThrottle(t *testing.T) {
+	c := New(exec.Command("bash", "-c", `while :; do :; done`))
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	th, err := c.Throttle(0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	c.Stop(ctx)
+	th.Stop()
+	<-c.reap()
+	if cpu := c.ProcessState.UserTime() + c.ProcessState.SystemTime(); cpu > time.Millisecond*500 {
+		t.Fatalf("expected about 200ms of CPU time, got %v", cpu)
+	}
+	if !c.stoppedGracefully() {
+		t.Fatal("expected the throttled process to handle the stop signal")
+	}
+}
+
+func TestThrottle_ProcessGroup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ctxexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	pidFile := filepath.Join(dir, "pid")
+	c := New(exec.Command("sh", "-c", `sh -c "while :; do :; done" & echo $! > `+pidFile+`; wait`), WithProcessGroup())
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	var pid []byte
+	for i := 0; i < 100 && len(pid) == 0; i++ {
+		time.Sleep(time.Millisecond * 10)
+		pid, _ = ioutil.ReadFile(pidFile)
+	}
+	th, err := c.Throttle(0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer th.Stop()
+	for i := 0; i < 20; i++ {
+		time.Sleep(time.Millisecond * 20)
+		out, _ := exec.Command("ps", "-o", "stat=", "-p", strings.TrimSpace(string(pid))).Output()
+		if strings.HasPrefix(string(out), "T") {
+			return
+		}
+	}
+	t.Fatal("expected the grandchild to be suspended")
+}
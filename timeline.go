@@ -0,0 +1,303 @@
+package ctxexec
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// EventKind identifies a lifecycle event of a command
+type EventKind int
+
+const (
+	// EventStarted is recorded once the process has started
+	EventStarted EventKind = iota
+	// EventFirstOutput is recorded on the first write to Stdout or Stderr
+	EventFirstOutput
+	// EventSignal is recorded for every signal sent while stopping
+	EventSignal
+	// EventKill is recorded when the process is killed
+	EventKill
+	// EventExit is recorded once the process exited and its pipes were closed
+	EventExit
+	// EventOutputTruncated is recorded when a RingBuffer of CaptureTail
+	// first discards output
+	EventOutputTruncated
+	// EventStartFailed is recorded when the command fails to start, no
+	// event follows it
+	EventStartFailed
+)
+
+var eventNames = map[EventKind]string{
+	EventStarted:     "start",
+	EventFirstOutput: "first output",
+	EventSignal:      "signal",
+	EventKill:        "kill",
+	EventExit:        "exit",
+
+	EventOutputTruncated: "output truncated",
+	EventStartFailed:     "start failed",
+}
+
+// String returns the name of the event kind
+func (k EventKind) String() string {
+	if n, ok := eventNames[k]; ok {
+		return n
+	}
+	return "unknown"
+}
+
+// Event is a timestamped lifecycle event
+type Event struct {
+	Time   time.Time
+	Kind   EventKind
+	Signal os.Signal // Signal is the signal sent, set for EventSignal
+	Err    error     // Err is the start error, set for EventStartFailed
+}
+
+// Timeline is the ordered list of lifecycle events of a command
+type Timeline []Event
+
+// timeline records lifecycle events, it is safe for concurrent use
+type timeline struct {
+	mu     sync.Mutex
+	events Timeline
+	output sync.Once
+	exit   sync.Once
+	outc   chan struct{} // outc is closed on the first output
+	start  sync.Once
+	startc chan struct{} // startc is closed once the start or its failure was recorded
+	subs   []chan Event
+	closed bool // closed is set once the subscribers were closed
+}
+
+// eventsBuffer is the number of events buffered for each subscriber
+// beyond the ones already recorded
+const eventsBuffer = 64
+
+func (t *timeline) record(kind EventKind, sig os.Signal) {
+	t.add(Event{Time: time.Now(), Kind: kind, Signal: sig})
+}
+
+// add appends the event and sends it to the subscribers
+func (t *timeline) add(e Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, e)
+	for _, ch := range t.subs {
+		select {
+		case ch <- e:
+		default: // never block the command on a slow subscriber
+		}
+	}
+}
+
+// subscribe returns a channel receiving the events recorded so far and the
+// ones to come, closed by closeSubscribers
+func (t *timeline) subscribe() <-chan Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ch := make(chan Event, len(t.events)+eventsBuffer)
+	for _, e := range t.events {
+		ch <- e
+	}
+	if t.closed {
+		close(ch)
+	} else {
+		t.subs = append(t.subs, ch)
+	}
+	return ch
+}
+
+// closeSubscribers closes the channels of the subscribers, later ones are
+// closed once the recorded events were sent
+func (t *timeline) closeSubscribers() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, ch := range t.subs {
+		close(ch)
+	}
+	t.subs, t.closed = nil, true
+}
+
+// recordStarted records EventStarted
+func (t *timeline) recordStarted() {
+	t.record(EventStarted, nil)
+	t.start.Do(func() { close(t.startChan()) })
+}
+
+// recordStartFailure records EventStartFailed and closes the subscribers,
+// as the process is never reaped
+func (t *timeline) recordStartFailure(err error) {
+	t.add(Event{Time: time.Now(), Kind: EventStartFailed, Err: err})
+	t.start.Do(func() { close(t.startChan()) })
+	t.closeSubscribers()
+}
+
+// startChan returns the channel closed once the start or its failure was
+// recorded
+func (t *timeline) startChan() chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.startc == nil {
+		t.startc = make(chan struct{})
+	}
+	return t.startc
+}
+
+// outputChan returns the channel closed on the first output
+func (t *timeline) outputChan() chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.outc == nil {
+		t.outc = make(chan struct{})
+	}
+	return t.outc
+}
+
+// recordExit records EventExit, only once
+func (t *timeline) recordExit() {
+	t.exit.Do(func() { t.record(EventExit, nil) })
+}
+
+func (t *timeline) snapshot() Timeline {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append(Timeline(nil), t.events...)
+}
+
+// Timeline returns the lifecycle events recorded so far.
+//
+// The first output is only recorded when Stdout or Stderr is a
+// writer other than an *os.File.
+func (c *CtxCmd) Timeline() Timeline {
+	return c.timeline.snapshot()
+}
+
+// startedAt returns the time the process started, zero if it hasn't
+func (c *CtxCmd) startedAt() time.Time {
+	for _, e := range c.Timeline() {
+		if e.Kind == EventStarted {
+			return e.Time
+		}
+	}
+	return time.Time{}
+}
+
+// Events returns a channel receiving the lifecycle events of the command,
+// starting with the ones recorded so far, so monitoring code can react to
+// them without polling. The channel is closed once the process was reaped
+// by Wait or Done, or once it failed to start. Events are dropped rather
+// than blocking the command when the receiver falls behind by more than 64
+// events.
+func (c *CtxCmd) Events() <-chan Event {
+	return c.timeline.subscribe()
+}
+
+// firstOutputWriter records EventFirstOutput on the first write
+type firstOutputWriter struct {
+	io.Writer
+	t *timeline
+}
+
+func (w *firstOutputWriter) Write(p []byte) (int, error) {
+	w.t.output.Do(func() {
+		// the process may write before its start was recorded
+		<-w.t.startChan()
+		w.t.record(EventFirstOutput, nil)
+		close(w.t.outputChan())
+	})
+	return w.Writer.Write(p)
+}
+
+// watchOutputs wraps the command's Stdout and Stderr to record the first
+// output. A writer shared by both is wrapped once, so that exec still
+// calls it from a single goroutine.
+func (t *timeline) watchOutputs(cmd *exec.Cmd) {
+	shared := interfaceEqual(cmd.Stdout, cmd.Stderr)
+	cmd.Stdout = t.watchOutput(cmd.Stdout)
+	if shared {
+		cmd.Stderr = cmd.Stdout
+	} else {
+		cmd.Stderr = t.watchOutput(cmd.Stderr)
+	}
+}
+
+// interfaceEqual protects against panics from doing equality tests on
+// two interfaces with non-comparable underlying types
+func interfaceEqual(a, b interface{}) (equal bool) {
+	defer func() {
+		if recover() != nil {
+			equal = false
+		}
+	}()
+	return a == b
+}
+
+// watchOutput wraps w to record the first output, files are left untouched
+// so they are still handed to the process directly
+func (t *timeline) watchOutput(w io.Writer) io.Writer {
+	if w == nil {
+		return nil
+	}
+	if _, ok := w.(*os.File); ok {
+		return w
+	}
+	return &firstOutputWriter{Writer: w, t: t}
+}
+
+type cmdKey struct{}
+
+// withCmd returns a copy of the context carrying the CtxCmd, so that
+// stop functions can record events against it
+func withCmd(ctx context.Context, c *CtxCmd) context.Context {
+	return context.WithValue(ctx, cmdKey{}, c)
+}
+
+// record records an event against the CtxCmd carried by the context, if any
+func record(ctx context.Context, kind EventKind, sig os.Signal) {
+	if c, ok := ctx.Value(cmdKey{}).(*CtxCmd); ok {
+		c.timeline.record(kind, sig)
+	}
+}
+
+// chromeEvent is an event in the Chrome trace event format
+type chromeEvent struct {
+	Name  string            `json:"name"`
+	Phase string            `json:"ph"`
+	Time  int64             `json:"ts"`
+	Dur   int64             `json:"dur,omitempty"`
+	Pid   int               `json:"pid"`
+	Tid   int               `json:"tid"`
+	Scope string            `json:"s,omitempty"`
+	Args  map[string]string `json:"args,omitempty"`
+}
+
+// WriteChromeTrace writes the timeline in the Chrome trace event format,
+// viewable with chrome://tracing or Perfetto. pid identifies the process
+// in the trace and labels are attached as arguments of the run span.
+func (t Timeline) WriteChromeTrace(w io.Writer, pid int, labels map[string]string) error {
+	events := []chromeEvent{}
+	var start time.Time
+	for _, e := range t {
+		ce := chromeEvent{Name: e.Kind.String(), Phase: "i", Time: e.Time.UnixNano() / 1e3, Pid: pid, Scope: "p"}
+		if e.Signal != nil {
+			ce.Args = map[string]string{"signal": e.Signal.String()}
+		}
+		events = append(events, ce)
+		switch e.Kind {
+		case EventStarted:
+			start = e.Time
+		case EventExit:
+			if !start.IsZero() {
+				events = append(events, chromeEvent{Name: "run", Phase: "X", Time: start.UnixNano() / 1e3, Dur: int64(e.Time.Sub(start) / time.Microsecond), Pid: pid, Args: labels})
+			}
+		}
+	}
+	return json.NewEncoder(w).Encode(map[string]interface{}{"traceEvents": events})
+}
@@ -0,0 +1,119 @@
+package ctxexec
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestTimeline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*500)
+	defer cancel()
+	var out bytes.Buffer
+	cmd := exec.Command("bash", "-c", `trap "" SIGINT SIGTERM; echo hello; while true; do sleep 1; done`)
+	cmd.Stdout = &out
+	c := New(cmd)
+	c.Run(ctx)
+
+	var kinds []EventKind
+	for _, e := range c.Timeline() {
+		kinds = append(kinds, e.Kind)
+	}
+	want := []EventKind{EventStarted, EventFirstOutput, EventSignal, EventSignal, EventKill, EventExit}
+	if len(kinds) != len(want) {
+		t.Fatalf("expected %v, got %v", want, kinds)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, kinds)
+		}
+	}
+}
+
+func TestTimeline_SharedOutput(t *testing.T) {
+	var out bytes.Buffer
+	cmd := exec.Command("bash", "-c", `echo out; echo err >&2`)
+	cmd.Stdout, cmd.Stderr = &out, &out
+	c := New(cmd)
+	c.Start()
+	if c.Cmd.Stdout != c.Cmd.Stderr {
+		t.Fatal("expected the shared writer to stay shared")
+	}
+	c.Cmd.Wait()
+}
+
+func TestTimeline_WriteChromeTrace(t *testing.T) {
+	now := time.Now()
+	tl := Timeline{{Time: now, Kind: EventStarted}, {Time: now.Add(time.Second), Kind: EventExit}}
+	var buf bytes.Buffer
+	if err := tl.WriteChromeTrace(&buf, 42, map[string]string{"job": "etl"}); err != nil {
+		t.Fatal(err)
+	}
+	var trace struct {
+		TraceEvents []chromeEvent `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &trace); err != nil {
+		t.Fatal(err)
+	}
+	if len(trace.TraceEvents) != 3 {
+		t.Fatalf("expected 3 trace events, got %d", len(trace.TraceEvents))
+	}
+	if run := trace.TraceEvents[2]; run.Phase != "X" || run.Dur != 1e6 || run.Args["job"] != "etl" {
+		t.Fatalf("expected a one second run span, got %+v", run)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	c := New(exec.Command("echo", "hello world"))
+	c.CaptureTail(4, 0)
+	events := c.Events()
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var kinds []EventKind
+	for e := range events {
+		if e.Time.IsZero() {
+			t.Fatal("expected the event to be timestamped")
+		}
+		kinds = append(kinds, e.Kind)
+	}
+	want := []EventKind{EventStarted, EventFirstOutput, EventOutputTruncated, EventExit}
+	if len(kinds) != len(want) {
+		t.Fatalf("expected %v, got %v", want, kinds)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, kinds)
+		}
+	}
+
+	// late subscribers get the recorded events and a closed channel
+	n := 0
+	for range c.Events() {
+		n++
+	}
+	if n != len(want) {
+		t.Fatalf("expected %d replayed events, got %d", len(want), n)
+	}
+}
+
+func TestEvents_StartFailure(t *testing.T) {
+	c := New(exec.Command("/nonexistent/binary"))
+	events := c.Events()
+	err := c.Start()
+	if err == nil {
+		t.Fatal("expected the start to fail")
+	}
+	var got []Event
+	for e := range events {
+		got = append(got, e)
+	}
+	if len(got) != 1 || got[0].Kind != EventStartFailed || got[0].Err != err {
+		t.Fatalf("expected a start failure event, got %v", got)
+	}
+}
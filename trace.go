@@ -0,0 +1,55 @@
+package ctxexec
+
+import (
+	"regexp"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// TraceParentEnv is the environment variable holding the W3C traceparent
+	TraceParentEnv = "TRACEPARENT"
+	// TraceStateEnv is the environment variable holding the W3C tracestate
+	TraceStateEnv = "TRACESTATE"
+)
+
+type traceKey struct{}
+
+type traceContext struct {
+	parent, state string
+}
+
+// WithTraceParent returns a copy of the parent context carrying a W3C trace
+// context, the traceparent and optional tracestate headers of the active
+// span, for commands with PropagateTrace
+func WithTraceParent(parent context.Context, traceparent, tracestate string) context.Context {
+	return context.WithValue(parent, traceKey{}, traceContext{traceparent, tracestate})
+}
+
+// TraceContext returns the W3C traceparent and tracestate of the active
+// span of the context. It returns the ones set with WithTraceParent by
+// default, tracing integrations replace it to read their own spans.
+var TraceContext = func(ctx context.Context) (traceparent, tracestate string) {
+	tc, _ := ctx.Value(traceKey{}).(traceContext)
+	return tc.parent, tc.state
+}
+
+var traceParentRe = regexp.MustCompile(`^[0-9a-f]{2}-[0-9a-f]{32}-[0-9a-f]{16}-[0-9a-f]{2}$`)
+
+// propagateTrace adds TraceParentEnv and TraceStateEnv to the command's
+// environment when the context carries a valid trace context
+func (c *CtxCmd) propagateTrace(ctx context.Context) {
+	parent, state := TraceContext(ctx)
+	if !traceParentRe.MatchString(parent) {
+		if parent != "" {
+			logf(ctx, Errors, "invalid traceparent %q", parent)
+		}
+		return
+	}
+	envSet(c.Cmd, TraceParentEnv, parent)
+	if state != "" {
+		envSet(c.Cmd, TraceStateEnv, state)
+	} else {
+		envUnset(c.Cmd, TraceStateEnv)
+	}
+}
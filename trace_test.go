@@ -0,0 +1,33 @@
+package ctxexec
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestPropagateTrace(t *testing.T) {
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	var out bytes.Buffer
+	cmd := exec.Command("bash", "-c", `echo -n "$TRACEPARENT $TRACESTATE"`)
+	cmd.Stdout = &out
+	c := New(cmd)
+	c.PropagateTrace = true
+	if err := c.StartContext(WithTraceParent(context.Background(), parent, "vendor=1")); err != nil {
+		t.Fatal(err)
+	}
+	c.Cmd.Wait()
+	if want := parent + " vendor=1"; out.String() != want {
+		t.Fatalf("expected %q, got %q", want, out.String())
+	}
+
+	c = New(exec.Command("true"))
+	c.PropagateTrace = true
+	c.StartContext(WithTraceParent(context.Background(), "invalid", ""))
+	c.Cmd.Wait()
+	if envGet(c.Cmd, TraceParentEnv) == "invalid" {
+		t.Fatal("expected an invalid traceparent not to be propagated")
+	}
+}
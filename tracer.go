@@ -0,0 +1,67 @@
+package ctxexec
+
+import (
+	"path/filepath"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Span is a tracing span opened around a run
+type Span interface {
+	// SetAttribute sets an attribute of the span, value is a string, an
+	// int or an int64
+	SetAttribute(key string, value interface{})
+	// RecordError records the error the run failed with
+	RecordError(err error)
+	// End ends the span
+	End()
+}
+
+// Tracer opens the spans of the commands it is set on, it adapts a tracing
+// library such as OpenTelemetry without the package depending on it:
+//
+//	type otelTracer struct{ trace.Tracer }
+//
+//	func (t otelTracer) StartSpan(ctx context.Context, name string) (context.Context, ctxexec.Span) {
+//		ctx, span := t.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+//		return ctx, otelSpan{span}
+//	}
+//
+// The span must be a child of the active span of ctx, if any. The returned
+// context is the one the command runs with, so that PropagateTrace hands
+// the new span to the process when TraceContext reads it.
+type Tracer interface {
+	StartSpan(ctx context.Context, name string) (context.Context, Span)
+}
+
+// spanName returns the name of the span of the command, "exec" followed by
+// the base name of its path
+func (c *CtxCmd) spanName() string {
+	return "exec " + filepath.Base(c.Path)
+}
+
+// endSpan records the outcome of the run on the span and ends it. The
+// command line is redacted as by String.
+func (c *CtxCmd) endSpan(span Span, err error) {
+	span.SetAttribute("process.command_line", c.String())
+	if pid := pidOf(c.Cmd); pid > 0 {
+		span.SetAttribute("process.pid", pid)
+	}
+	if started := c.startedAt(); !started.IsZero() {
+		span.SetAttribute("process.duration_ms", int64(time.Since(started)/time.Millisecond))
+	}
+	if c.ProcessState != nil {
+		span.SetAttribute("process.exit.code", c.ProcessState.ExitCode())
+		if sig := exitSignal(c.ProcessState); sig != nil {
+			span.SetAttribute("process.exit.signal", sig.String())
+		}
+	}
+	for k, v := range c.Labels {
+		span.SetAttribute("ctxexec.label."+k, v)
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
@@ -0,0 +1,58 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+type testSpan struct {
+	parent string
+	attrs  map[string]interface{}
+	err    error
+	ended  bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *testSpan) RecordError(err error)                      { s.err = err }
+func (s *testSpan) End()                                       { s.ended = true }
+
+type testTracer struct{ spans []*testSpan }
+
+func (t *testTracer) StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := TraceContext(ctx)
+	s := &testSpan{parent: parent, attrs: map[string]interface{}{"name": name}}
+	t.spans = append(t.spans, s)
+	return ctx, s
+}
+
+func TestTracer(t *testing.T) {
+	tr := &testTracer{}
+	ctx := WithTraceParent(context.Background(), "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", "")
+	c := New(exec.Command("bash", "-c", "exit 3", "--password", "hunter2"), WithTracer(tr))
+	if err := c.Run(ctx); err == nil {
+		t.Fatal("expected an exit error")
+	}
+
+	if len(tr.spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(tr.spans))
+	}
+	s := tr.spans[0]
+	if !s.ended || s.err == nil {
+		t.Fatal("expected the span to end with the error")
+	}
+	if s.parent == "" {
+		t.Fatal("expected the span to be started with the parent context")
+	}
+	if s.attrs["name"] != "exec bash" {
+		t.Fatalf("unexpected span name %v", s.attrs["name"])
+	}
+	if s.attrs["process.exit.code"] != 3 {
+		t.Fatalf("expected exit code 3, got %v", s.attrs["process.exit.code"])
+	}
+	if cl, _ := s.attrs["process.command_line"].(string); cl == "" || strings.Contains(cl, "hunter2") {
+		t.Fatalf("expected a redacted command line, got %q", cl)
+	}
+}
@@ -0,0 +1,26 @@
+package ctxexec
+
+import (
+	"os"
+	"time"
+)
+
+// Usage is the resource consumption of an exited process, portably
+type Usage struct {
+	UserTime    time.Duration // UserTime is the user CPU time
+	SystemTime  time.Duration // SystemTime is the system CPU time
+	MaxRSS      uint64        // MaxRSS is the maximum resident set size in bytes, zero where unavailable
+	MinorFaults int64         // MinorFaults is the number of page faults serviced without I/O, zero where unavailable
+	MajorFaults int64         // MajorFaults is the number of page faults that required I/O, zero where unavailable
+}
+
+// usageOf returns the resource usage of the exited process, nil if it
+// didn't exit
+func usageOf(state *os.ProcessState) *Usage {
+	if state == nil {
+		return nil
+	}
+	u := &Usage{UserTime: state.UserTime(), SystemTime: state.SystemTime()}
+	sysUsage(state, u)
+	return u
+}
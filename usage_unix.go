@@ -0,0 +1,24 @@
+//go:build !windows
+// +build !windows
+
+package ctxexec
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// sysUsage sets the memory statistics of the usage from the rusage of the
+// process
+func sysUsage(state *os.ProcessState, u *Usage) {
+	ru, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return
+	}
+	u.MaxRSS = uint64(ru.Maxrss)
+	if runtime.GOOS != "darwin" && runtime.GOOS != "ios" {
+		u.MaxRSS *= 1024 // in KiB elsewhere
+	}
+	u.MinorFaults, u.MajorFaults = int64(ru.Minflt), int64(ru.Majflt)
+}
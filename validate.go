@@ -0,0 +1,100 @@
+package ctxexec
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ArgError is returned for invalid command arguments, environment or directory
+type ArgError struct {
+	Field  string // Field is what is invalid: "path", "arg", "env" or "dir"
+	Value  string // Value is the invalid value
+	Reason string // Reason describes why the value is invalid
+}
+
+func (e *ArgError) Error() string {
+	return fmt.Sprintf("ctxexec: invalid %s %q: %s", e.Field, e.Value, e.Reason)
+}
+
+// Validate checks the command's path, arguments, environment and directory
+// for NUL bytes and malformed environment variables, which are either
+// rejected by exec with an opaque error or silently truncated. It also
+// checks the directory exists.
+func Validate(cmd *exec.Cmd) error {
+	if strings.IndexByte(cmd.Path, 0) >= 0 {
+		return &ArgError{Field: "path", Value: cmd.Path, Reason: "contains a NUL byte"}
+	}
+	for _, arg := range cmd.Args {
+		if strings.IndexByte(arg, 0) >= 0 {
+			return &ArgError{Field: "arg", Value: arg, Reason: "contains a NUL byte"}
+		}
+	}
+	if strings.IndexByte(cmd.Dir, 0) >= 0 {
+		return &ArgError{Field: "dir", Value: cmd.Dir, Reason: "contains a NUL byte"}
+	}
+	if cmd.Dir != "" {
+		if fi, err := os.Stat(cmd.Dir); os.IsNotExist(err) {
+			return &ArgError{Field: "dir", Value: cmd.Dir, Reason: "doesn't exist"}
+		} else if err == nil && !fi.IsDir() {
+			return &ArgError{Field: "dir", Value: cmd.Dir, Reason: "is not a directory"}
+		}
+	}
+	for _, kv := range cmd.Env {
+		if strings.IndexByte(kv, 0) >= 0 {
+			return &ArgError{Field: "env", Value: kv, Reason: "contains a NUL byte"}
+		}
+		name := kv
+		if runtime.GOOS == "windows" && strings.HasPrefix(kv, "=") {
+			// the current directories of the drives, such as =C:=C:\dir,
+			// are in the environment of Windows processes
+			name = kv[1:]
+		}
+		i := strings.IndexByte(name, '=')
+		if i < 0 {
+			return &ArgError{Field: "env", Value: kv, Reason: "is not in the KEY=value form"}
+		}
+		if i == 0 {
+			return &ArgError{Field: "env", Value: kv, Reason: "has an empty name"}
+		}
+	}
+	return nil
+}
+
+// createDir creates the command's directory, and its parents, with
+// DirMode when it is missing
+func (c *CtxCmd) createDir() error {
+	if c.DirMode == 0 || c.Dir == "" {
+		return nil
+	}
+	return os.MkdirAll(c.Dir, c.DirMode)
+}
+
+// UserArgs checks positional arguments built from user input and returns
+// them. It returns an *ArgError for arguments the command could parse as
+// flags or that contain NUL bytes.
+func UserArgs(args ...string) ([]string, error) {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			return nil, &ArgError{Field: "arg", Value: arg, Reason: "starts with '-'"}
+		}
+		if strings.IndexByte(arg, 0) >= 0 {
+			return nil, &ArgError{Field: "arg", Value: arg, Reason: "contains a NUL byte"}
+		}
+	}
+	return args, nil
+}
+
+// EndOfFlags returns positional arguments built from user input preceded
+// by "--" when any of them starts with '-', so that commands following the
+// convention can't parse them as flags.
+func EndOfFlags(args ...string) []string {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			return append([]string{"--"}, args...)
+		}
+	}
+	return args
+}
@@ -0,0 +1,72 @@
+package ctxexec
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	nul := exec.Command("echo", "a\x00b")
+	env := exec.Command("env")
+	env.Env = []string{"=value"}
+	for _, cmd := range []*exec.Cmd{nul, env} {
+		if _, ok := New(cmd).Start().(*ArgError); !ok {
+			t.Fatalf("expected %q to be rejected", cmd.Args)
+		}
+	}
+	if err := Validate(exec.Command("echo", "ok")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidate_DriveEnv(t *testing.T) {
+	cmd := exec.Command("env")
+	cmd.Env = []string{`=C:=C:\dir`}
+	if err := Validate(cmd); (err == nil) != (runtime.GOOS == "windows") {
+		t.Fatalf("expected drive directories allowed only on windows, got %v", err)
+	}
+}
+
+func TestDir(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ctxexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	dir := filepath.Join(tmp, "a", "b")
+	cmd := exec.Command("true")
+	cmd.Dir = dir
+	if err, ok := New(cmd).Start().(*ArgError); !ok || err.Field != "dir" {
+		t.Fatalf("expected a missing dir to be rejected, got %v", err)
+	}
+	c := New(exec.Command("true"))
+	c.Dir = dir
+	c.DirMode = 0700
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	c.Cmd.Wait()
+	if fi, err := os.Stat(dir); err != nil || fi.Mode().Perm() != 0700 {
+		t.Fatalf("expected the dir to be created, got %v", err)
+	}
+}
+
+func TestUserArgs(t *testing.T) {
+	if _, err := UserArgs("file.txt", "-rf"); err == nil {
+		t.Fatal("expected flag-like argument to be rejected")
+	}
+	if args, err := UserArgs("file.txt"); err != nil || len(args) != 1 {
+		t.Fatalf("unexpected %v, %v", args, err)
+	}
+	if args := EndOfFlags("-rf", "x"); !reflect.DeepEqual(args, []string{"--", "-rf", "x"}) {
+		t.Fatalf("expected -- to be inserted, got %v", args)
+	}
+	if args := EndOfFlags("x"); !reflect.DeepEqual(args, []string{"x"}) {
+		t.Fatalf("expected args unchanged, got %v", args)
+	}
+}
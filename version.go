@@ -0,0 +1,172 @@
+package ctxexec
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// VersionFlags are the arguments printing the version of binaries that
+// don't support --version
+var VersionFlags = map[string][]string{
+	"ffmpeg":  {"-version"},
+	"ffprobe": {"-version"},
+	"go":      {"version"},
+	"java":    {"-version"},
+	"ssh":     {"-V"},
+}
+
+// SemVer is a semantic version, as far as a tool's version output goes
+type SemVer struct {
+	Major, Minor, Patch int
+}
+
+func (v SemVer) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0 or 1 if v is lower than, equal to or greater than w
+func (v SemVer) Compare(w SemVer) int {
+	for _, d := range []int{v.Major - w.Major, v.Minor - w.Minor, v.Patch - w.Patch} {
+		switch {
+		case d < 0:
+			return -1
+		case d > 0:
+			return 1
+		}
+	}
+	return 0
+}
+
+var versionRe = regexp.MustCompile(`(\d+)(?:\.(\d+))?(?:\.(\d+))?`)
+
+// ParseVersion returns the first version found in s, such as 4.2.1 in
+// "ffmpeg version 4.2.1-0ubuntu1". Missing minor and patch numbers are 0.
+func ParseVersion(s string) (SemVer, error) {
+	m := versionRe.FindStringSubmatch(s)
+	if m == nil {
+		return SemVer{}, fmt.Errorf("ctxexec: no version in %q", s)
+	}
+	var n [3]int
+	for i, digits := range m[1:] {
+		if digits != "" {
+			n[i], _ = strconv.Atoi(digits)
+		}
+	}
+	return SemVer{n[0], n[1], n[2]}, nil
+}
+
+// Satisfies returns true if v satisfies all the comma separated
+// constraints, made of an operator among =, !=, <, <=, > and >=, = when
+// omitted, and a version as understood by ParseVersion, such as ">=4.2, <5"
+func (v SemVer) Satisfies(constraints string) (bool, error) {
+	for _, c := range strings.Split(constraints, ",") {
+		c = strings.TrimSpace(c)
+		op := strings.TrimRight(c, "0123456789. ")
+		w, err := ParseVersion(c[len(op):])
+		if err != nil {
+			return false, fmt.Errorf("ctxexec: invalid version constraint %q", c)
+		}
+		cmp := v.Compare(w)
+		var ok bool
+		switch op {
+		case "", "=", "==":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		default:
+			return false, fmt.Errorf("ctxexec: invalid version constraint %q", c)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+var versions struct {
+	sync.Mutex
+	cache map[string]SemVer
+}
+
+// Version runs the named binary with its VersionFlags, --version by
+// default, and returns the version it prints. Versions are cached by the
+// binary's path for the life of the program.
+func Version(ctx context.Context, name string) (SemVer, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return SemVer{}, err
+	}
+	versions.Lock()
+	v, ok := versions.cache[path]
+	versions.Unlock()
+	if ok {
+		return v, nil
+	}
+	args, ok := VersionFlags[name]
+	if !ok {
+		args = []string{"--version"}
+	}
+	var out bytes.Buffer
+	c := New(exec.Command(path, args...))
+	c.Stdout, c.Stderr = &out, &out // some tools, such as java, print it on stderr
+	if err := c.StartContext(ctx); err != nil {
+		return SemVer{}, err
+	}
+	if err := waitExit(ctx, c); err != nil {
+		return SemVer{}, fmt.Errorf("ctxexec: %s version: %v", name, err)
+	}
+	if v, err = ParseVersion(out.String()); err != nil {
+		return SemVer{}, err
+	}
+	versions.Lock()
+	if versions.cache == nil {
+		versions.cache = map[string]SemVer{}
+	}
+	versions.cache[path] = v
+	versions.Unlock()
+	return v, nil
+}
+
+// VersionError is returned when a binary's version doesn't satisfy the
+// required constraints
+type VersionError struct {
+	Name        string // Name is the binary's name
+	Version     SemVer // Version is the binary's version
+	Constraints string // Constraints are the required ones
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("ctxexec: %s %v is installed, %s is required", e.Name, e.Version, e.Constraints)
+}
+
+// RequireVersion returns a *VersionError if the version of the named
+// binary doesn't satisfy the constraints, see Satisfies
+func RequireVersion(ctx context.Context, name, constraints string) error {
+	v, err := Version(ctx, name)
+	if err != nil {
+		return err
+	}
+	ok, err := v.Satisfies(constraints)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &VersionError{Name: name, Version: v, Constraints: constraints}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package ctxexec
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestParseVersion(t *testing.T) {
+	for s, want := range map[string]SemVer{
+		"ffmpeg version 4.2.1-0ubuntu1": {4, 2, 1},
+		"GNU bash, version 5.1":         {5, 1, 0},
+		"v12":                           {12, 0, 0},
+	} {
+		if v, err := ParseVersion(s); err != nil || v != want {
+			t.Errorf("%q: expected %v, got %v, %v", s, want, v, err)
+		}
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	v := SemVer{4, 2, 1}
+	for c, want := range map[string]bool{
+		">=4.2":      true,
+		">=4.2, <5":  true,
+		"<4.2":       false,
+		"4.2.1":      true,
+		"!=4.2.1":    false,
+		">4, <=4.2":  false,
+		">4, <=4.3":  true,
+		"=4.2.1, >5": false,
+	} {
+		if ok, err := v.Satisfies(c); err != nil || ok != want {
+			t.Errorf("%q: expected %v, got %v, %v", c, want, ok, err)
+		}
+	}
+	if _, err := v.Satisfies("~>4"); err == nil {
+		t.Error("expected an error for an unknown operator")
+	}
+}
+
+func TestRequireVersion(t *testing.T) {
+	ctx := context.Background()
+	if err := RequireVersion(ctx, "bash", ">=2"); err != nil {
+		t.Fatal(err)
+	}
+	err := RequireVersion(ctx, "bash", ">=100")
+	if verr, ok := err.(*VersionError); !ok || verr.Version.Major < 2 {
+		t.Fatalf("expected a *VersionError, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package ctxexec
+
+import (
+	"golang.org/x/net/context"
+)
+
+// WaitAny waits for the first of the started commands to exit and returns
+// its index along with the error Wait would return for it.
+//
+// When the context is done first, all the commands are stopped as by Wait
+// and WaitAny returns -1 and the context's error.
+func WaitAny(ctx context.Context, cmds ...*CtxCmd) (int, error) {
+	exited := make(chan int, len(cmds))
+	for i, c := range cmds {
+		go func(i int, c *CtxCmd) {
+			<-c.reap()
+			exited <- i
+		}(i, c)
+	}
+	select {
+	case i := <-exited:
+		return i, cmds[i].exitError()
+	case <-ctx.Done():
+		WaitAll(ctx, cmds...)
+		return -1, ctx.Err()
+	}
+}
+
+// WaitAll waits for all the started commands to exit and returns their
+// errors, in the order of the commands, as Wait would return them.
+//
+// When the context is done, the commands still running are stopped.
+func WaitAll(ctx context.Context, cmds ...*CtxCmd) []error {
+	errs := make([]error, len(cmds))
+	done := make(chan struct{})
+	for i, c := range cmds {
+		go func(i int, c *CtxCmd) {
+			errs[i] = waitExit(ctx, c)
+			done <- struct{}{}
+		}(i, c)
+	}
+	for range cmds {
+		<-done
+	}
+	return errs
+}
+
+// waitExit waits for the process to exit, stopping it as Wait does when the
+// context is done or the package-managed deadline passes first
+func waitExit(ctx context.Context, c *CtxCmd) error {
+	wctx, release := c.watchdog.watch(ctx)
+	defer release()
+	select {
+	case <-c.reap():
+		return c.exitError()
+	case <-wctx.Done():
+		return c.Wait(ctx)
+	}
+}
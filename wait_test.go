@@ -0,0 +1,60 @@
+package ctxexec
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestWaitAny(t *testing.T) {
+	slow := New(exec.Command("sleep", "5"))
+	fast := New(exec.Command("bash", "-c", "exit 2"))
+	slow.Start()
+	fast.Start()
+	defer slow.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+	i, err := WaitAny(ctx, slow, fast)
+	if i != 1 {
+		t.Fatalf("expected the fast command to exit first, got %d", i)
+	}
+	if err == nil {
+		t.Fatal("expected an exit error")
+	}
+}
+
+func TestWaitAll(t *testing.T) {
+	a := New(exec.Command("true"))
+	b := New(exec.Command("sleep", "5"))
+	a.Start()
+	b.Start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*500)
+	defer cancel()
+	start := time.Now()
+	errs := WaitAll(ctx, a, b)
+	if time.Since(start) > time.Second*2 {
+		t.Fatal("expected the remaining command to be stopped")
+	}
+	if errs[0] != nil {
+		t.Fatalf("expected success, got %v", errs[0])
+	}
+	if errs[1] == nil || !b.stopped() {
+		t.Fatalf("expected the sleep to be stopped, got %v", errs[1])
+	}
+}
+
+func TestWaitAll_SuccessExitCodes(t *testing.T) {
+	c := New(exec.Command("bash", "-c", "exit 1"))
+	c.SuccessExitCodes = []int{1}
+	c.Start()
+	if errs := WaitAll(context.Background(), c); errs[0] != nil {
+		t.Fatalf("expected exit code 1 to succeed, got %v", errs[0])
+	}
+	if code := c.Cmd.ProcessState.ExitCode(); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+}
@@ -0,0 +1,178 @@
+package ctxexec
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// WatchInterval is how often the resource usage of commands with limits,
+// such as MaxMemory or MaxDirSize, is sampled
+var WatchInterval = time.Second
+
+// ErrMemoryLimitExceeded is the StopCause of commands stopped for using
+// more than MaxMemory
+var ErrMemoryLimitExceeded = errors.New("ctxexec: memory limit exceeded")
+
+// ErrDiskQuotaExceeded is the StopCause of commands stopped for writing
+// more than MaxDirSize to their working directory
+var ErrDiskQuotaExceeded = errors.New("ctxexec: disk quota exceeded")
+
+// StopCause returns the reason the package stopped the command on its own,
+// such as ErrMemoryLimitExceeded, or nil
+func (c *CtxCmd) StopCause() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cause
+}
+
+// stopFor records the cause and stops the command in the background, giving
+// it Grace to exit. Only the first cause is recorded.
+func (c *CtxCmd) stopFor(ctx context.Context, cause error) {
+	c.mu.Lock()
+	if c.cause != nil {
+		c.mu.Unlock()
+		return
+	}
+	c.cause = cause
+	c.mu.Unlock()
+	ctx = withCmd(detach(ctx), c)
+	logf(ctx, Errors, "pid %d: %v, stopping", pidOf(c.Cmd), cause)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, c.grace())
+		defer cancel()
+		c.Stop(ctx)
+	}()
+}
+
+// watch calls check every interval until the process exits, stopping the
+// command with the error check returns. It stops watching if check fails to
+// sample the usage.
+func (c *CtxCmd) watch(ctx context.Context, interval time.Duration, check func() (exceeded error, err error)) {
+	ctx = withCmd(detach(ctx), c)
+	exited := c.reap()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-exited:
+			return
+		case <-ticker.C:
+		}
+		exceeded, err := check()
+		if err != nil {
+			select {
+			case <-exited:
+			default:
+				logf(ctx, Errors, "pid %d: %v", pidOf(c.Cmd), err)
+			}
+			return
+		}
+		if exceeded != nil {
+			c.stopFor(ctx, exceeded)
+			return
+		}
+	}
+}
+
+// watchMemory stops the command once it uses more than MaxMemory
+func (c *CtxCmd) watchMemory(ctx context.Context) {
+	c.labelGoroutine()
+	pid := c.Process.Pid
+	c.watch(ctx, WatchInterval, func() (error, error) {
+		rss, err := memoryUsage(pid, c.MaxMemoryTree)
+		if err != nil || rss <= c.MaxMemory {
+			return nil, err
+		}
+		return ErrMemoryLimitExceeded, nil
+	})
+}
+
+// watchDir stops the command once its working directory holds more
+// than MaxDirSize
+func (c *CtxCmd) watchDir(ctx context.Context) {
+	c.labelGoroutine()
+	dir := c.Dir
+	if dir == "" {
+		dir = "."
+	}
+	c.watch(ctx, WatchInterval, func() (error, error) {
+		size, err := dirSize(dir)
+		if err != nil || size <= c.MaxDirSize {
+			return nil, err
+		}
+		return ErrDiskQuotaExceeded, nil
+	})
+}
+
+// dirSize returns the total size of the regular files under dir
+func dirSize(dir string) (uint64, error) {
+	var size uint64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil // removed while walking
+			}
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += uint64(info.Size())
+		}
+		return nil
+	})
+	return size, err
+}
+
+// procInfo is an entry of the process table
+type procInfo struct {
+	ppid int
+	rss  uint64        // rss is the resident memory in bytes
+	cpu  time.Duration // cpu is the user and system CPU time consumed
+}
+
+// memoryUsage returns the resident memory of the process, and of its
+// descendants when tree is true
+func memoryUsage(pid int, tree bool) (uint64, error) {
+	p, err := resourceUsage(pid, tree)
+	return p.rss, err
+}
+
+// resourceUsage returns the resident memory and CPU time of the process,
+// and of its descendants when tree is true
+func resourceUsage(pid int, tree bool) (procInfo, error) {
+	procs, err := processTable()
+	if err != nil {
+		return procInfo{}, err
+	}
+	p, ok := procs[pid]
+	if !ok {
+		return procInfo{}, errors.New("ctxexec: process not found")
+	}
+	if !tree {
+		return p, nil
+	}
+	for _, pid := range descendants(procs, pid) {
+		p.rss += procs[pid].rss
+		p.cpu += procs[pid].cpu
+	}
+	return p, nil
+}
+
+// descendants returns the pids of the descendants of the process
+func descendants(procs map[int]procInfo, pid int) []int {
+	children := map[int][]int{}
+	for pid, p := range procs {
+		children[p.ppid] = append(children[p.ppid], pid)
+	}
+	var pids []int
+	queue := children[pid]
+	for len(queue) > 0 {
+		pid, queue = queue[0], queue[1:]
+		pids = append(pids, pid)
+		queue = append(queue, children[pid]...)
+	}
+	return pids
+}
@@ -0,0 +1,66 @@
+package ctxexec
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestMemoryUsage(t *testing.T) {
+	rss, err := memoryUsage(os.Getpid(), true)
+	if err != nil || rss == 0 {
+		t.Fatalf("expected the memory usage of the test, got %d, %v", rss, err)
+	}
+}
+
+func TestMaxMemory(t *testing.T) {
+	defer func(d time.Duration) { WatchInterval = d }(WatchInterval)
+	WatchInterval = time.Millisecond * 20
+	c := New(exec.Command("bash", "-c", `x=$(head -c 50000000 /dev/zero | tr '\0' a); sleep 5`))
+	c.MaxMemory = 20 << 20
+	c.MaxMemoryTree = true
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-c.reap():
+	case <-time.After(time.Second * 3):
+		c.Process.Kill()
+		t.Fatal("expected the command to be stopped over its memory limit")
+	}
+	if err := c.StopCause(); err != ErrMemoryLimitExceeded {
+		t.Fatalf("expected %v, got %v", ErrMemoryLimitExceeded, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := c.Wait(ctx); err == nil {
+		t.Fatal("expected an error from the stopped command")
+	}
+}
+
+func TestMaxDirSize(t *testing.T) {
+	defer func(d time.Duration) { WatchInterval = d }(WatchInterval)
+	WatchInterval = time.Millisecond * 20
+	dir, err := ioutil.TempDir("", "ctxexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c := New(exec.Command("bash", "-c", `mkdir sub; for i in $(seq 100); do head -c 100000 /dev/zero >sub/$i; sleep 0.01; done`))
+	c.Dir = dir
+	c.MaxDirSize = 1 << 20
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	<-c.reap()
+	if err := c.StopCause(); err != ErrDiskQuotaExceeded {
+		t.Fatalf("expected %v, got %v", ErrDiskQuotaExceeded, err)
+	}
+	if size, _ := dirSize(dir); size > 4<<20 {
+		t.Fatalf("expected the command to be stopped shortly after exceeding the quota, wrote %d bytes", size)
+	}
+}